@@ -0,0 +1,173 @@
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "time"
+    "crontab-tui/parser"
+    "crontab-tui/source"
+)
+
+const usageText = `Usage: crontab-tui [-f file [--system-format]] [-u user] [--system] [--all] [--root dir] [--readonly] [--tz zone]
+       crontab-tui <command> [options] [arguments]
+
+Browse and edit crontabs in the terminal. Without options the current
+user's crontab is read with "crontab -l" and installed with "crontab -".
+
+Commands, for scripts (crontab-tui <command> -h for details):
+  list       print the jobs of a crontab
+  describe   explain a schedule expression
+  next       print the next times a schedule fires
+  validate   check crontab files for invalid lines
+  lint       report likely mistakes in a crontab
+  add        append a job to a crontab
+  remove     delete the job on a given line
+  env        print the environment cron runs a job with
+  import     add the jobs of a json or yaml job list
+
+Exit status: 0 success, 1 invalid input or crontab, 2 usage error,
+3 a crontab could not be read or written, 4 no job on the given line.
+
+Options:
+`
+
+type options struct {
+    file         string
+    user         string
+    system       bool
+    all          bool
+    root         string
+    readonly     bool
+    systemFormat bool
+    crontabBin   string
+    backupDir    string
+    keepBackups  int
+    staged       bool
+    zone         string
+    displayZone  *time.Location
+}
+
+func newFlagSet(opts *options, output io.Writer) *flag.FlagSet {
+    fs := flag.NewFlagSet("crontab-tui", flag.ContinueOnError)
+    fs.SetOutput(output)
+    addSourceFlags(fs, opts)
+    fs.BoolVar(&opts.staged, "staged", false, "collect changes in memory until saved with w (S toggles this in the TUI)")
+    fs.StringVar(&opts.zone, "tz", "", "show next runs in time `zone`, e.g. UTC or Europe/Berlin (default: the system's, z changes it in the TUI)")
+    fs.Usage = func() {
+        fmt.Fprint(fs.Output(), usageText)
+        fs.PrintDefaults()
+    }
+    return fs
+}
+
+// addSourceFlags registers the options choosing the crontab, shared by the
+// TUI and the subcommands.
+func addSourceFlags(fs *flag.FlagSet, opts *options) {
+    fs.StringVar(&opts.file, "f", "", "edit the crontab `file` directly instead of a user's crontab")
+    fs.StringVar(&opts.user, "u", "", "edit the crontab of `user` (needs the right to run crontab -u)")
+    fs.BoolVar(&opts.system, "system", false, "edit the system crontabs, /etc/crontab and /etc/cron.d/*")
+    fs.BoolVar(&opts.all, "all", false, "show every job on the host: user spool, system crontabs and run-parts directories (read-only)")
+    fs.StringVar(&opts.root, "root", "/", "look up system and spool paths below `directory` instead of /")
+    fs.BoolVar(&opts.readonly, "readonly", false, "only view, never write anything")
+    fs.BoolVar(&opts.systemFormat, "system-format", false, "read the -f file like /etc/crontab, with a user column (automatic for /etc/crontab and /etc/cron.d)")
+    fs.StringVar(&opts.crontabBin, "crontab-bin", source.DefaultCrontabBinary, "crontab `program` used to read and install user crontabs")
+    fs.StringVar(&opts.backupDir, "backup-dir", source.DefaultBackupDir(), "keep backups of changed crontabs in `directory`")
+    fs.IntVar(&opts.keepBackups, "keep-backups", source.DefaultBackupKeep, "number of backups kept per crontab, 0 disables backups")
+}
+
+func parseFlags(args []string, output io.Writer) (*options, error) {
+    opts := &options{}
+    fs := newFlagSet(opts, output)
+    if err := fs.Parse(args); err != nil {
+        return nil, err
+    }
+    if fs.NArg() > 0 {
+        return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+    }
+    if err := opts.validate(); err != nil {
+        return nil, err
+    }
+    return opts, nil
+}
+
+// validate checks the source options once parsed.
+func (opts *options) validate() error {
+    selected := 0
+    for _, set := range []bool{opts.file != "", opts.user != "", opts.system, opts.all} {
+        if set {
+            selected++
+        }
+    }
+    if selected > 1 {
+        return errors.New("-f, -u, --system and --all cannot be combined")
+    }
+    loc, err := parser.LoadZone(opts.zone)
+    if err != nil {
+        return fmt.Errorf("--tz: %v", err)
+    }
+    opts.displayZone = loc
+    if opts.keepBackups < 0 {
+        return errors.New("--keep-backups cannot be negative")
+    }
+    if opts.systemFormat && opts.file == "" {
+        return errors.New("--system-format only applies to -f")
+    }
+    if opts.file != "" {
+        info, err := os.Stat(opts.file)
+        if err != nil {
+            return err
+        }
+        if !info.Mode().IsRegular() {
+            return fmt.Errorf("%s is not a regular file", opts.file)
+        }
+    }
+    return nil
+}
+
+// sources resolves the options into the crontabs to show.
+func (opts *options) sources() ([]source.Source, error) {
+    var sources []source.Source
+    switch {
+    case opts.file != "" && (opts.systemFormat || source.IsSystemPath(opts.file)):
+        sources = []source.Source{source.NewSystemFileSource(opts.file)}
+    case opts.file != "":
+        sources = []source.Source{source.NewFileSource(opts.file)}
+    case opts.all:
+        var err error
+        sources, err = source.AllSources(opts.root)
+        if err != nil {
+            return nil, err
+        }
+        if len(sources) == 0 {
+            return nil, fmt.Errorf("no cron sources found below %s", opts.root)
+        }
+    case opts.system:
+        var err error
+        sources, err = source.SystemSources(opts.root)
+        if err != nil {
+            return nil, err
+        }
+        if len(sources) == 0 {
+            return nil, fmt.Errorf("no system crontabs found")
+        }
+    default:
+        sources = []source.Source{&source.CommandSource{Binary: opts.crontabBin, User: opts.user}}
+    }
+
+    if opts.readonly || opts.all {
+        for i, src := range sources {
+            sources[i] = source.ReadOnly(src)
+        }
+        return sources, nil
+    }
+    if opts.keepBackups > 0 {
+        store := &source.BackupStore{Dir: opts.backupDir, Keep: opts.keepBackups}
+        for i, src := range sources {
+            sources[i] = source.WithBackups(src, store)
+        }
+    }
+    return sources, nil
+}
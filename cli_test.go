@@ -0,0 +1,55 @@
+package main
+
+import (
+    "io"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestParseFlags(t *testing.T) {
+    dir := t.TempDir()
+    file := filepath.Join(dir, "crontab")
+    if err := os.WriteFile(file, []byte("@daily /bin/true\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        args    []string
+        wantErr bool
+    }{
+        {nil, false},
+        {[]string{"-f", file}, false},
+        {[]string{"-u", "alice"}, false},
+        {[]string{"--system"}, false},
+        {[]string{"-f", file, "--readonly"}, false},
+        {[]string{"-f", file, "--system-format"}, false},
+        {[]string{"--all", "--root", dir}, false},
+        {[]string{"-f", file, "--keep-backups", "0"}, false},
+        {[]string{"--tz", "Europe/Berlin"}, false},
+
+        {[]string{"-f", file, "-u", "alice"}, true},
+        {[]string{"-f", file, "--system"}, true},
+        {[]string{"-u", "alice", "--system"}, true},
+        {[]string{"-f", filepath.Join(dir, "missing")}, true},
+        {[]string{"-f", dir}, true},
+        {[]string{"--system-format"}, true},
+        {[]string{"--system", "--system-format"}, true},
+        {[]string{"-u", "alice", "--all"}, true},
+        {[]string{"--system", "--all"}, true},
+        {[]string{"-f", file, "-u", "alice", "--system", "--all"}, true},
+        {[]string{"--keep-backups", "-1"}, true},
+        {[]string{"--tz", "Mars/Olympus"}, true},
+        {[]string{"extra"}, true},
+    }
+
+    for _, tt := range tests {
+        _, err := parseFlags(tt.args, io.Discard)
+        if tt.wantErr && err == nil {
+            t.Errorf("parseFlags(%q) accepted, want an error", tt.args)
+        }
+        if !tt.wantErr && err != nil {
+            t.Errorf("parseFlags(%q): %v", tt.args, err)
+        }
+    }
+}
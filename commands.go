@@ -0,0 +1,503 @@
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "time"
+    "crontab-tui/diff"
+    "crontab-tui/lint"
+    "crontab-tui/parser"
+    "crontab-tui/runner"
+    "crontab-tui/source"
+    "crontab-tui/utils"
+)
+
+// Exit codes of the subcommands, part of the interface for scripts.
+const (
+    exitOK       = 0
+    exitInvalid  = 1
+    exitUsage    = 2
+    exitIO       = 3
+    exitNotFound = 4
+)
+
+// command is a headless subcommand, run instead of the TUI when it is the
+// first argument.
+type command struct {
+    name    string
+    args    string
+    summary string
+    run     func(c *commandContext, args []string) int
+}
+
+// commandContext is what a subcommand runs with.
+type commandContext struct {
+    name   string
+    flags  *flag.FlagSet
+    args   []string
+    opts   *options
+    stdout io.Writer
+    stderr io.Writer
+}
+
+var commands = []command{
+    {"list", "[--format text|json|yaml] [--runs N] [--tz zone] [source options]", "Print the jobs of a crontab. The text format has one line per job with tab\nseparated columns: source:line, enabled, disabled or invalid, schedule, next run,\nuser, command. json and yaml add the description and the next run times.", runList},
+    {"describe", "<schedule>", "Explain a schedule given as five fields or an @-special.", runDescribe},
+    {"next", "[-n count] [--from time] [--tz zone] <schedule>", "Print the next times a schedule fires. With --tz the schedule is evaluated\nin that zone like under a CRON_TZ line, and the times carry the zone's\nabbreviation.", runNext},
+    {"validate", "[--system-format] <file>...", "Report the lines of crontab files that cron would not accept, and warn about\n% signs that cut a command short. Only rejected lines make it exit with 1.", runValidate},
+    {"lint", "[--severity info|warning|error] [--format text|json] [source options]", "Report invalid lines and likely mistakes, with the rule that found them and\na suggested fix. Exits with 1 when there is an error.", runLint},
+    {"add", "[source options] <schedule> [user] <command>", "Append a job to a crontab, validated like in the TUI.", runAdd},
+    {"remove", "[source options] --line N", "Delete the job, enabled or disabled, on line N of a crontab.", runRemove},
+    {"env", "[--diff] [source options] --line N", "Print the environment cron starts the job on line N with, one NAME=value per\nline in cron's order. With --diff it is shown next to the current environment.", runEnv},
+    {"import", "[--replace [--force]] [--dry-run] [--format json|yaml] [source options] <file>", "Add the jobs of a json or yaml job list, as written by list, that the crontab\ndoes not have yet. With --replace, jobs added by an earlier import that are\nnot in the list are removed, which an empty list only does with --force.\nThe change is printed as a unified diff.", runImport},
+}
+
+func findCommand(name string) (command, bool) {
+    for _, c := range commands {
+        if c.name == name {
+            return c, true
+        }
+    }
+    return command{}, false
+}
+
+// runCommand runs a subcommand and returns its exit code.
+func runCommand(c command, args []string, stdout, stderr io.Writer) int {
+    ctx := &commandContext{name: c.name, opts: &options{}, stdout: stdout, stderr: stderr}
+    ctx.flags = flag.NewFlagSet("crontab-tui "+c.name, flag.ContinueOnError)
+    ctx.flags.SetOutput(stderr)
+    ctx.flags.Usage = func() {
+        fmt.Fprintf(stderr, "Usage: crontab-tui %s %s\n\n%s\n", c.name, c.args, c.summary)
+        fmt.Fprintln(stderr, "\nOptions:")
+        ctx.flags.PrintDefaults()
+    }
+    return c.run(ctx, args)
+}
+
+// parse parses flags given before, between or after the arguments.
+func (c *commandContext) parse(args []string) int {
+    for {
+        if err := c.flags.Parse(args); err != nil {
+            if err == flag.ErrHelp {
+                return exitOK
+            }
+            return exitUsage
+        }
+        if c.flags.NArg() == 0 {
+            return -1
+        }
+        c.args = append(c.args, c.flags.Arg(0))
+        args = c.flags.Args()[1:]
+    }
+}
+
+func (c *commandContext) fail(code int, err error) int {
+    fmt.Fprintf(c.stderr, "crontab-tui %s: %v\n", c.name, err)
+    return code
+}
+
+func (c *commandContext) usage(format string, a ...interface{}) int {
+    c.fail(exitUsage, fmt.Errorf(format, a...))
+    c.flags.Usage()
+    return exitUsage
+}
+
+// sources parses the source options shared with the TUI.
+func (c *commandContext) sources(args []string) ([]source.Source, int) {
+    addSourceFlags(c.flags, c.opts)
+    if code := c.parse(args); code >= 0 {
+        return nil, code
+    }
+    if err := c.opts.validate(); err != nil {
+        return nil, c.fail(exitUsage, err)
+    }
+    sources, err := c.opts.sources()
+    if err != nil {
+        return nil, c.fail(exitIO, err)
+    }
+    return sources, -1
+}
+
+// writable is the one crontab add and remove change.
+func (c *commandContext) writable(args []string) (source.Source, int) {
+    sources, code := c.sources(args)
+    if code >= 0 {
+        return nil, code
+    }
+    if len(sources) != 1 || c.opts.all {
+        return nil, c.usage("%s changes a single crontab, select it with -f or -u", c.name)
+    }
+    return sources[0], -1
+}
+
+// scheduleArgs accepts a schedule either quoted as one argument or as
+// separate fields.
+func scheduleArgs(args []string) []string {
+    return strings.Fields(strings.Join(args, " "))
+}
+
+func runList(c *commandContext, args []string) int {
+    format := c.flags.String("format", "text", "output `format`: text, json or yaml")
+    runs := c.flags.Int("runs", exportRuns, "number of next run times in json and yaml")
+    zone := c.flags.String("tz", "", "show next runs in time `zone` (default: the system's)")
+    sources, code := c.sources(args)
+    if code >= 0 {
+        return code
+    }
+    if len(c.args) > 0 {
+        return c.usage("unexpected argument %q", c.args[0])
+    }
+    if *format != "text" && *format != "json" && *format != "yaml" {
+        return c.usage("unknown format %q", *format)
+    }
+    if *runs < 0 {
+        return c.usage("--runs cannot be negative")
+    }
+    loc, err := parser.LoadZone(*zone)
+    if err != nil {
+        return c.usage("--tz: %v", err)
+    }
+    now := time.Now().In(loc)
+    result, errs := source.LoadAll(sources)
+    if *format != "text" {
+        if err := writeResultAt(c.stdout, result, *format, now, *runs); err != nil {
+            return c.fail(exitIO, err)
+        }
+    } else {
+        listText(c.stdout, result, now)
+    }
+    for _, err := range errs {
+        c.fail(exitIO, err)
+    }
+    if len(errs) > 0 {
+        return exitIO
+    }
+    return exitOK
+}
+
+func listText(w io.Writer, result *parser.Result, now time.Time) {
+    for _, job := range result.CronJobs {
+        state := "enabled"
+        if job.Disabled {
+            state = "disabled"
+        }
+        if job.Err != nil {
+            state = "invalid"
+        }
+        user := job.User
+        if user == "" {
+            user = "-"
+        }
+        next := parser.FormatRunTime(job.NextRun(now))
+        if job.Via == parser.ViaAnacron {
+            next = "via anacron"
+        }
+        fmt.Fprintf(w, "%s:%d\t%s\t%s\t%s\t%s\t%s\n", job.Source, job.LineNumber, state,
+            strings.Join(job.Schedule, " "), next, user, job.Command)
+    }
+}
+
+func runDescribe(c *commandContext, args []string) int {
+    if code := c.parse(args); code >= 0 {
+        return code
+    }
+    fields := scheduleArgs(c.args)
+    if len(fields) == 0 {
+        return c.usage("missing schedule")
+    }
+    if _, err := parser.ParseSchedule(fields); err != nil {
+        return c.fail(exitInvalid, err)
+    }
+    fmt.Fprintln(c.stdout, parser.Describe(fields))
+    return exitOK
+}
+
+func runNext(c *commandContext, args []string) int {
+    count := c.flags.Int("n", 5, "number of fire times to print")
+    from := c.flags.String("from", "", "start after `time` (2006-01-02 15:04) instead of now")
+    zone := c.flags.String("tz", "", "evaluate the schedule in time `zone` like CRON_TZ (default: the system's)")
+    if code := c.parse(args); code >= 0 {
+        return code
+    }
+    fields := scheduleArgs(c.args)
+    if len(fields) == 0 {
+        return c.usage("missing schedule")
+    }
+    if *count < 1 {
+        return c.usage("-n must be at least 1")
+    }
+    loc, err := parser.LoadZone(*zone)
+    if err != nil {
+        return c.usage("--tz: %v", err)
+    }
+    after := time.Now().In(loc)
+    if *from != "" {
+        t, err := time.ParseInLocation("2006-01-02 15:04", *from, loc)
+        if err != nil {
+            return c.usage("invalid --from time %q, use 2006-01-02 15:04", *from)
+        }
+        after = t
+    }
+    spec, err := parser.ParseSchedule(fields)
+    if err != nil {
+        return c.fail(exitInvalid, err)
+    }
+    if spec.Reboot {
+        return c.fail(exitInvalid, errors.New("@reboot runs at startup only"))
+    }
+    runs := spec.NextN(after, *count)
+    if len(runs) == 0 {
+        return c.fail(exitInvalid, errors.New("the schedule never fires"))
+    }
+    for _, t := range runs {
+        if *zone != "" {
+            fmt.Fprintf(c.stdout, "%s %s\n", parser.FormatRunTime(t), t.Format("MST"))
+            continue
+        }
+        fmt.Fprintln(c.stdout, parser.FormatRunTime(t))
+    }
+    return exitOK
+}
+
+func runValidate(c *commandContext, args []string) int {
+    systemFormat := c.flags.Bool("system-format", false, "read the files like /etc/crontab, with a user column (automatic for /etc/crontab and /etc/cron.d)")
+    if code := c.parse(args); code >= 0 {
+        return code
+    }
+    if len(c.args) == 0 {
+        return c.usage("missing file")
+    }
+    code := exitOK
+    for _, path := range c.args {
+        data, err := os.ReadFile(path)
+        if err != nil {
+            c.fail(exitIO, err)
+            code = exitIO
+            continue
+        }
+        format := parser.UserFormat
+        if *systemFormat || source.IsSystemPath(path) {
+            format = parser.SystemFormat
+        }
+        for _, line := range parser.ParseDocumentFormat(data, format).Lines {
+            var problem error
+            switch {
+            case line.Kind == parser.LineInvalid:
+                problem = line.Err
+            case line.Kind == parser.LineJob && format == parser.SystemFormat && !line.Job.Disabled:
+                problem = utils.ValidateUser(line.Job.User)
+            }
+            if problem != nil {
+                fmt.Fprintf(c.stdout, "%s:%d: %v\n", path, line.Number, problem)
+                if code == exitOK {
+                    code = exitInvalid
+                }
+            }
+            if line.Kind == parser.LineJob && !line.Job.Disabled {
+                if warning := percentWarning(line.Job.Command); warning != "" {
+                    fmt.Fprintf(c.stdout, "%s:%d: warning: %s\n", path, line.Number, warning)
+                }
+            }
+        }
+    }
+    return code
+}
+
+func runLint(c *commandContext, args []string) int {
+    format := c.flags.String("format", "text", "output `format`: text or json")
+    severity := c.flags.String("severity", "info", "report only diagnostics of at least this `level`")
+    sources, code := c.sources(args)
+    if code >= 0 {
+        return code
+    }
+    if len(c.args) > 0 {
+        return c.usage("unexpected argument %q", c.args[0])
+    }
+    if *format != "text" && *format != "json" {
+        return c.usage("unknown format %q", *format)
+    }
+    min, err := lint.ParseSeverity(*severity)
+    if err != nil {
+        return c.usage("%v", err)
+    }
+    diagnostics, errs := lintSources(sources)
+    var shown []lint.Diagnostic
+    for _, d := range diagnostics {
+        if d.Severity >= min {
+            shown = append(shown, d)
+        }
+    }
+    if err := writeDiagnostics(c.stdout, shown, *format); err != nil {
+        return c.fail(exitIO, err)
+    }
+    for _, err := range errs {
+        c.fail(exitIO, err)
+    }
+    if len(errs) > 0 {
+        return exitIO
+    }
+    if lint.Count(diagnostics, lint.Error) > 0 {
+        return exitInvalid
+    }
+    return exitOK
+}
+
+func runAdd(c *commandContext, args []string) int {
+    src, code := c.writable(args)
+    if code >= 0 {
+        return code
+    }
+    if len(c.args) == 0 {
+        return c.usage("missing job")
+    }
+    schedule, user, cmd, err := parseJobInput(strings.Join(c.args, " "), src.Format())
+    if err != nil {
+        return c.fail(exitInvalid, errors.New(strings.ReplaceAll(err.Error(), "\n", " ")))
+    }
+    if warning := percentWarning(cmd); warning != "" {
+        fmt.Fprintf(c.stderr, "crontab-tui %s: warning: %s, write \\%% to keep it\n", c.name, warning)
+    }
+    if err := AppendCrontabJob(src, schedule, user, cmd); err != nil {
+        return c.fail(exitIO, err)
+    }
+    return exitOK
+}
+
+// errNoJob is returned by remove for a line that holds no job.
+var errNoJob = errors.New("no job on that line")
+
+func runRemove(c *commandContext, args []string) int {
+    line := c.flags.Int("line", 0, "remove the job on line `N`")
+    src, code := c.writable(args)
+    if code >= 0 {
+        return code
+    }
+    if len(c.args) > 0 {
+        return c.usage("unexpected argument %q", c.args[0])
+    }
+    if *line < 1 {
+        return c.usage("--line is required")
+    }
+    err := mutateCrontab(src, fmt.Sprintf("delete line %d", *line), func(doc *parser.Document) error {
+        l, err := doc.LineAt(*line)
+        if err != nil || l.Kind != parser.LineJob {
+            return errNoJob
+        }
+        return doc.DeleteLine(*line)
+    })
+    if err == errNoJob {
+        return c.fail(exitNotFound, fmt.Errorf("line %d: %v", *line, err))
+    }
+    if err != nil {
+        return c.fail(exitIO, err)
+    }
+    return exitOK
+}
+
+func runEnv(c *commandContext, args []string) int {
+    line := c.flags.Int("line", 0, "the job on line `N`")
+    compare := c.flags.Bool("diff", false, "compare with the current environment")
+    sources, code := c.sources(args)
+    if code >= 0 {
+        return code
+    }
+    if len(c.args) > 0 {
+        return c.usage("unexpected argument %q", c.args[0])
+    }
+    if len(sources) != 1 || c.opts.all {
+        return c.usage("env reads a single crontab, select it with -f or -u")
+    }
+    if *line < 1 {
+        return c.usage("--line is required")
+    }
+    src := sources[0]
+    result, err := source.Load(src)
+    if err != nil {
+        return c.fail(exitIO, err)
+    }
+    var job *parser.CronJob
+    for i := range result.CronJobs {
+        if result.CronJobs[i].LineNumber == *line && result.CronJobs[i].Err == nil {
+            job = &result.CronJobs[i]
+        }
+    }
+    if job == nil {
+        return c.fail(exitNotFound, fmt.Errorf("line %d: %v", *line, errNoJob))
+    }
+    account, err := runner.LookupAccount(job, source.Owner(src))
+    if err != nil {
+        return c.fail(exitInvalid, err)
+    }
+    env := runner.Environment(job, account)
+    if *compare {
+        if err := runner.WriteComparison(c.stdout, runner.CompareEnvironment(env, os.Environ()), "CURRENT", 40, false); err != nil {
+            return c.fail(exitIO, err)
+        }
+        return exitOK
+    }
+    for _, kv := range env {
+        fmt.Fprintln(c.stdout, kv)
+    }
+    return exitOK
+}
+
+// errDryRun aborts an import after the diff was computed.
+var errDryRun = errors.New("dry run")
+
+func runImport(c *commandContext, args []string) int {
+    replace := c.flags.Bool("replace", false, "remove imported jobs missing from the list")
+    dryRun := c.flags.Bool("dry-run", false, "print the diff without writing")
+    force := c.flags.Bool("force", false, "with --replace, accept an empty list and remove every managed job")
+    format := c.flags.String("format", "", "`format` of the file, json or yaml (default: from its name)")
+    src, code := c.writable(args)
+    if code >= 0 {
+        return code
+    }
+    if len(c.args) != 1 {
+        return c.usage("expected one file to import")
+    }
+    if *format != "" && *format != "json" && *format != "yaml" {
+        return c.usage("unknown format %q", *format)
+    }
+    plan, err := ReadImport(c.args[0], *format, src.Format())
+    if errors.Is(err, errInvalidJobs) {
+        return c.fail(exitInvalid, err)
+    }
+    if err != nil {
+        if _, ok := err.(*os.PathError); ok {
+            return c.fail(exitIO, err)
+        }
+        return c.fail(exitInvalid, err)
+    }
+    plan.Replace = *replace
+    plan.Force = *force
+
+    var before, after []byte
+    err = mutateCrontab(src, plan.action(), func(doc *parser.Document) error {
+        before = doc.Bytes()
+        if err := plan.Apply(doc); err != nil {
+            return err
+        }
+        after = doc.Bytes()
+        if *dryRun {
+            return errDryRun
+        }
+        return nil
+    })
+    if err == errEmptyReplace {
+        return c.fail(exitInvalid, fmt.Errorf("%v, use --force to do it anyway", err))
+    }
+    if errors.Is(err, errInvalidJobs) {
+        return c.fail(exitInvalid, err)
+    }
+    if err != nil && err != errDryRun {
+        return c.fail(exitIO, err)
+    }
+    fmt.Fprint(c.stdout, diff.Unified(src.Name(), src.Name(), before, after, 3))
+    fmt.Fprintf(c.stderr, "%s\n", plan.summary())
+    return exitOK
+}
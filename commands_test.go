@@ -0,0 +1,149 @@
+package main
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func run(t *testing.T, args ...string) (int, string) {
+    t.Helper()
+    c, ok := findCommand(args[0])
+    if !ok {
+        t.Fatalf("no command %s", args[0])
+    }
+    var stdout, stderr bytes.Buffer
+    code := runCommand(c, args[1:], &stdout, &stderr)
+    return code, stdout.String()
+}
+
+func TestCommandExitCodes(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "crontab")
+    content := "# jobs\n*/5 * * * * /bin/sh -c true\n#0 9 * * MON-FRI /bin/sh -c date\n61 * * * * /bin/sh\n"
+    if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+    dated := filepath.Join(dir, "dated")
+    if err := os.WriteFile(dated, []byte("0 3 * * * /bin/date +%F\n0 4 * * * /bin/date +\\%F\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    backups := filepath.Join(dir, "backups")
+
+    tests := []struct {
+        args []string
+        code int
+        out  string
+    }{
+        {[]string{"describe", "0 9 * * MON-FRI"}, exitOK, "Every Monday through Friday at 9:00 AM\n"},
+        {[]string{"describe", "0", "9", "*", "*", "*"}, exitOK, "Every day at 9:00 AM\n"},
+        {[]string{"describe", "0 99 * * *"}, exitInvalid, ""},
+        {[]string{"describe"}, exitUsage, ""},
+        {[]string{"next", "*/20 * * * *", "-n", "2", "--from", "2026-01-01 00:00"}, exitOK, "2026-01-01 00:20\n2026-01-01 00:40\n"},
+        {[]string{"next", "30 2 * * *", "-n", "2", "--tz", "America/New_York", "--from", "2026-03-07 12:00"}, exitOK, "2026-03-08 03:00 EDT\n2026-03-09 02:30 EDT\n"},
+        {[]string{"next", "--tz", "Mars/Olympus", "@daily"}, exitUsage, ""},
+        {[]string{"next", "0 0 30 2 *"}, exitInvalid, ""},
+        {[]string{"next", "-n", "0", "@daily"}, exitUsage, ""},
+        {[]string{"validate", path}, exitInvalid, path + ":4: minute field '61' invalid: value 61 out of range (0-59)\n"},
+        {[]string{"validate", filepath.Join(dir, "missing")}, exitIO, ""},
+        {[]string{"validate", dated}, exitOK, dated + ":1: warning: the % in \"+%F\" ends the command, cron passes the rest as standard input\n"},
+        {[]string{"lint", "-f", path}, exitInvalid, path + ":4:1: error: minute field '61' invalid: value 61 out of range (0-59) [invalid-field]\n"},
+        {[]string{"lint", "-f", path, "--severity", "fatal"}, exitUsage, ""},
+        {[]string{"list", "-f", path}, exitOK, path + ":2\tenabled\t*/5 * * * *\t"},
+        {[]string{"list", "-f", path, "--format", "json", "--runs", "-3"}, exitUsage, ""},
+        {[]string{"env", "-f", path, "--line", "2"}, exitOK, "SHELL=/bin/sh\nHOME="},
+        {[]string{"env", "-f", path, "--line", "4"}, exitNotFound, ""},
+        {[]string{"env", "-f", path}, exitUsage, ""},
+        {[]string{"remove", "-f", path, "--backup-dir", backups, "--line", "1"}, exitNotFound, ""},
+        {[]string{"remove", "-f", path, "--backup-dir", backups, "--line", "3"}, exitOK, ""},
+        {[]string{"remove", "-f", path, "--readonly", "--line", "2"}, exitIO, ""},
+        {[]string{"add", "-f", path, "--backup-dir", backups, "@daily /bin/sh -c true"}, exitOK, ""},
+        {[]string{"add", "-f", path, "--backup-dir", backups, "@daily"}, exitInvalid, ""},
+        {[]string{"add", "--system", "--root", dir, "@daily root /bin/sh"}, exitIO, ""},
+    }
+    for _, tt := range tests {
+        t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+            code, out := run(t, tt.args...)
+            if code != tt.code {
+                t.Errorf("exit %d, want %d", code, tt.code)
+            }
+            if !strings.HasPrefix(out, tt.out) {
+                t.Errorf("output %q, want prefix %q", out, tt.out)
+            }
+        })
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := "# jobs\n*/5 * * * * /bin/sh -c true\n61 * * * * /bin/sh\n@daily /bin/sh -c true\n"
+    if string(data) != want {
+        t.Errorf("crontab is %q, want %q", data, want)
+    }
+}
+
+func TestCommandImport(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "crontab")
+    backups := filepath.Join(dir, "backups")
+    if err := os.WriteFile(path, []byte("# mine\n@daily /bin/sh\n"+importMarker+"\n@hourly /bin/sh -c old\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    list := filepath.Join(dir, "jobs.yaml")
+    yaml := "jobs:\n  - schedule: \"@daily\"\n    command: /bin/sh\n  - schedule: \"*/5 * * * *\"\n    command: /bin/sh -c new\n    enabled: false\n"
+    if err := os.WriteFile(list, []byte(yaml), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    check := func(args []string, code int, want string) {
+        t.Helper()
+        got, _ := run(t, append([]string{"import", "-f", path, "--backup-dir", backups}, args...)...)
+        if got != code {
+            t.Errorf("import %v: exit %d, want %d", args, got, code)
+        }
+        data, err := os.ReadFile(path)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if string(data) != want {
+            t.Errorf("import %v: crontab is %q, want %q", args, data, want)
+        }
+    }
+    merged := "# mine\n@daily /bin/sh\n" + importMarker + "\n@hourly /bin/sh -c old\n" + importMarker + "\n# */5 * * * * /bin/sh -c new\n"
+    check([]string{"--dry-run", list}, exitOK, "# mine\n@daily /bin/sh\n"+importMarker+"\n@hourly /bin/sh -c old\n")
+    check([]string{list}, exitOK, merged)
+    check([]string{list}, exitOK, merged)
+    check([]string{"--replace", list}, exitOK, "# mine\n@daily /bin/sh\n"+importMarker+"\n# */5 * * * * /bin/sh -c new\n")
+
+    if err := os.WriteFile(list, []byte("[{\"schedule\": \"0 25 * * *\", \"command\": \"/bin/sh\"}]"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    check([]string{"--format", "json", list}, exitInvalid, "# mine\n@daily /bin/sh\n"+importMarker+"\n# */5 * * * * /bin/sh -c new\n")
+    check([]string{filepath.Join(dir, "missing.json")}, exitIO, "# mine\n@daily /bin/sh\n"+importMarker+"\n# */5 * * * * /bin/sh -c new\n")
+
+    kept := "# mine\n@daily /bin/sh\n" + importMarker + "\n# */5 * * * * /bin/sh -c new\n"
+    if err := os.WriteFile(list, []byte("job:\n  - schedule: \"@daily\"\n    command: /bin/sh\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    check([]string{"--replace", list}, exitInvalid, kept)
+    if err := os.WriteFile(list, []byte("jobs: []\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    check([]string{list}, exitOK, kept)
+    check([]string{"--replace", list}, exitInvalid, kept)
+    check([]string{"--replace", "--force", list}, exitOK, "# mine\n@daily /bin/sh\n")
+
+    if err := os.WriteFile(list, []byte("jobs:\n  - schedule: \"0 9 * * *\"\n    command: /bin/sh -c tokyo\n    timezone: Asia/Tokyo\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    check([]string{list}, exitInvalid, "# mine\n@daily /bin/sh\n")
+    if err := os.WriteFile(path, []byte("CRON_TZ=Asia/Tokyo\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    tokyo := "CRON_TZ=Asia/Tokyo\n" + importMarker + "\n0 9 * * * /bin/sh -c tokyo\n"
+    check([]string{list}, exitOK, tokyo)
+    check([]string{"--replace", list}, exitOK, tokyo)
+}
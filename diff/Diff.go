@@ -0,0 +1,107 @@
+// Package diff compares crontabs line by line and merges concurrent
+// changes to them.
+package diff
+
+import (
+    "strings"
+)
+
+type OpKind int
+
+const (
+    Equal OpKind = iota
+    Delete
+    Insert
+)
+
+// Op is one line of an edit script turning a into b.
+type Op struct {
+    Kind OpKind
+    Line string
+}
+
+// Lines splits data into lines that keep their line ending, so joining
+// them gives back data exactly.
+func Lines(data []byte) []string {
+    if len(data) == 0 {
+        return nil
+    }
+    lines := strings.SplitAfter(string(data), "\n")
+    if lines[len(lines)-1] == "" {
+        lines = lines[:len(lines)-1]
+    }
+    return lines
+}
+
+func Join(lines []string) []byte {
+    return []byte(strings.Join(lines, ""))
+}
+
+// Compute returns the shortest edit script from a to b. Crontabs are
+// small, the quadratic LCS table is fine.
+func Compute(a, b []string) []Op {
+    lcs := lcsTable(a, b)
+    var ops []Op
+    i, j := 0, 0
+    for i < len(a) && j < len(b) {
+        switch {
+        case a[i] == b[j]:
+            ops = append(ops, Op{Equal, a[i]})
+            i++
+            j++
+        case lcs[i+1][j] >= lcs[i][j+1]:
+            ops = append(ops, Op{Delete, a[i]})
+            i++
+        default:
+            ops = append(ops, Op{Insert, b[j]})
+            j++
+        }
+    }
+    for ; i < len(a); i++ {
+        ops = append(ops, Op{Delete, a[i]})
+    }
+    for ; j < len(b); j++ {
+        ops = append(ops, Op{Insert, b[j]})
+    }
+    return ops
+}
+
+// lcsTable holds in [i][j] the length of the longest common subsequence
+// of a[i:] and b[j:].
+func lcsTable(a, b []string) [][]int {
+    lcs := make([][]int, len(a)+1)
+    for i := range lcs {
+        lcs[i] = make([]int, len(b)+1)
+    }
+    for i := len(a) - 1; i >= 0; i-- {
+        for j := len(b) - 1; j >= 0; j-- {
+            if a[i] == b[j] {
+                lcs[i][j] = lcs[i+1][j+1] + 1
+            } else {
+                lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+            }
+        }
+    }
+    return lcs
+}
+
+// matches maps every line of a that is kept in b to its index in b, -1
+// for deleted lines.
+func matches(a, b []string) []int {
+    match := make([]int, len(a))
+    i, j := 0, 0
+    for _, op := range Compute(a, b) {
+        switch op.Kind {
+        case Equal:
+            match[i] = j
+            i++
+            j++
+        case Delete:
+            match[i] = -1
+            i++
+        case Insert:
+            j++
+        }
+    }
+    return match
+}
@@ -0,0 +1,145 @@
+package diff
+
+import (
+    "fmt"
+)
+
+// Choice says which side of a conflicting hunk goes into the merge.
+type Choice int
+
+const (
+    Unresolved Choice = iota
+    ChooseMine
+    ChooseTheirs
+    ChooseBoth
+)
+
+// Hunk is a run of lines that is either unchanged, changed on one side
+// only (or the same way on both), or a conflict between two changes.
+type Hunk struct {
+    Base     []string
+    Mine     []string
+    Theirs   []string
+    Conflict bool
+    Choice   Choice
+}
+
+// Changed reports whether the hunk differs from the base.
+func (h Hunk) Changed() bool {
+    return h.Conflict || !equal(h.Base, h.Mine) || !equal(h.Base, h.Theirs)
+}
+
+// Lines is what the hunk contributes to the merge, nil for an unresolved
+// conflict.
+func (h Hunk) Lines() []string {
+    if !h.Conflict {
+        if equal(h.Mine, h.Base) {
+            return h.Theirs
+        }
+        return h.Mine
+    }
+    switch h.Choice {
+    case ChooseMine:
+        return h.Mine
+    case ChooseTheirs:
+        return h.Theirs
+    case ChooseBoth:
+        return append(append([]string{}, h.Mine...), h.Theirs...)
+    }
+    return nil
+}
+
+// Merge is a line based three-way merge in the style of diff3: lines kept
+// by both sides anchor the merge, the changes between anchors are taken
+// from whichever side made them and conflict when both did differently.
+func Merge(base, mine, theirs []string) []Hunk {
+    matchMine := matches(base, mine)
+    matchTheirs := matches(base, theirs)
+
+    var hunks []Hunk
+    stable := func(line string) {
+        if n := len(hunks); n > 0 && !hunks[n-1].Changed() {
+            h := &hunks[n-1]
+            h.Base = append(h.Base, line)
+            h.Mine, h.Theirs = h.Base, h.Base
+            return
+        }
+        lines := []string{line}
+        hunks = append(hunks, Hunk{Base: lines, Mine: lines, Theirs: lines})
+    }
+
+    i, j, k := 0, 0, 0
+    for i < len(base) || j < len(mine) || k < len(theirs) {
+        if i < len(base) && matchMine[i] == j && matchTheirs[i] == k {
+            stable(base[i])
+            i, j, k = i+1, j+1, k+1
+            continue
+        }
+        // Find the next base line both sides kept.
+        ni, nj, nk := i, len(mine), len(theirs)
+        for ; ni < len(base); ni++ {
+            if matchMine[ni] >= 0 && matchTheirs[ni] >= 0 {
+                nj, nk = matchMine[ni], matchTheirs[ni]
+                break
+            }
+        }
+        h := Hunk{Base: base[i:ni], Mine: mine[j:nj], Theirs: theirs[k:nk]}
+        if conflicting(h) && len(h.Base) > 1 && len(h.Mine) == len(h.Base) && len(h.Theirs) == len(h.Base) {
+            // Both sides rewrote the same lines in place: merge them line
+            // by line, so edits to neighbouring lines do not conflict.
+            for n := range h.Base {
+                if h.Mine[n] == h.Base[n] && h.Theirs[n] == h.Base[n] {
+                    stable(h.Base[n])
+                    continue
+                }
+                line := Hunk{Base: h.Base[n : n+1], Mine: h.Mine[n : n+1], Theirs: h.Theirs[n : n+1]}
+                line.Conflict = conflicting(line)
+                hunks = append(hunks, line)
+            }
+        } else {
+            h.Conflict = conflicting(h)
+            hunks = append(hunks, h)
+        }
+        i, j, k = ni, nj, nk
+    }
+    return hunks
+}
+
+func conflicting(h Hunk) bool {
+    return !equal(h.Mine, h.Base) && !equal(h.Theirs, h.Base) && !equal(h.Mine, h.Theirs)
+}
+
+// Conflicts counts the conflicting hunks.
+func Conflicts(hunks []Hunk) int {
+    n := 0
+    for _, h := range hunks {
+        if h.Conflict {
+            n++
+        }
+    }
+    return n
+}
+
+// Resolve joins the merged hunks, failing while a conflict has no choice.
+func Resolve(hunks []Hunk) ([]byte, error) {
+    var lines []string
+    for n, h := range hunks {
+        if h.Conflict && h.Choice == Unresolved {
+            return nil, fmt.Errorf("hunk %d is still in conflict", n+1)
+        }
+        lines = append(lines, h.Lines()...)
+    }
+    return Join(lines), nil
+}
+
+func equal(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
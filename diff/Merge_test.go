@@ -0,0 +1,82 @@
+package diff
+
+import (
+    "testing"
+)
+
+func lines(s string) []string {
+    return Lines([]byte(s))
+}
+
+func TestMerge(t *testing.T) {
+    base := "# jobs\n0 1 * * * a\n0 2 * * * b\n0 3 * * * c\n"
+    tests := []struct {
+        name      string
+        mine      string
+        theirs    string
+        want      string
+        conflicts int
+    }{
+        {"unchanged", base, base, base, 0},
+        {"only mine", "# jobs\n0 1 * * * a\n0 2 * * * B\n0 3 * * * c\n", base,
+            "# jobs\n0 1 * * * a\n0 2 * * * B\n0 3 * * * c\n", 0},
+        {"only theirs", base, "# jobs\n0 1 * * * a\n0 3 * * * c\n",
+            "# jobs\n0 1 * * * a\n0 3 * * * c\n", 0},
+        {"different lines", "# jobs\n0 1 * * * A\n0 2 * * * b\n0 3 * * * c\n",
+            "# jobs\n0 1 * * * a\n0 2 * * * b\n0 3 * * * C\n",
+            "# jobs\n0 1 * * * A\n0 2 * * * b\n0 3 * * * C\n", 0},
+        {"both append", base + "x\n", base + "x\n", base + "x\n", 0},
+        {"neighbouring lines", "# jobs\n0 1 * * * A\n0 2 * * * b\n0 3 * * * c\n",
+            "# jobs\n0 1 * * * a\n0 2 * * * B\n0 3 * * * c\n",
+            "# jobs\n0 1 * * * A\n0 2 * * * B\n0 3 * * * c\n", 0},
+        {"same line", "# jobs\n0 1 * * * a\n0 2 * * * mine\n0 3 * * * c\n",
+            "# jobs\n0 1 * * * a\n0 2 * * * theirs\n0 3 * * * c\n", "", 1},
+        {"append and append", base + "mine\n", base + "theirs\n", "", 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            hunks := Merge(lines(base), lines(tt.mine), lines(tt.theirs))
+            if got := Conflicts(hunks); got != tt.conflicts {
+                t.Fatalf("Conflicts = %d, want %d", got, tt.conflicts)
+            }
+            merged, err := Resolve(hunks)
+            if tt.conflicts > 0 {
+                if err == nil {
+                    t.Fatalf("Resolve succeeded with unresolved conflicts")
+                }
+                return
+            }
+            if err != nil || string(merged) != tt.want {
+                t.Errorf("Resolve = %q, %v, want %q", merged, err, tt.want)
+            }
+        })
+    }
+}
+
+func TestMergeChoices(t *testing.T) {
+    base := lines("a\nb\nc\n")
+    hunks := Merge(base, lines("a\nmine\nc\n"), lines("a\ntheirs\nc\n"))
+    for choice, want := range map[Choice]string{
+        ChooseMine:   "a\nmine\nc\n",
+        ChooseTheirs: "a\ntheirs\nc\n",
+        ChooseBoth:   "a\nmine\ntheirs\nc\n",
+    } {
+        for i := range hunks {
+            if hunks[i].Conflict {
+                hunks[i].Choice = choice
+            }
+        }
+        merged, err := Resolve(hunks)
+        if err != nil || string(merged) != want {
+            t.Errorf("choice %d: %q, %v, want %q", choice, merged, err, want)
+        }
+    }
+}
+
+func TestLinesRoundTrip(t *testing.T) {
+    for _, s := range []string{"", "a", "a\n", "a\r\nb", "a\n\n"} {
+        if got := string(Join(Lines([]byte(s)))); got != s {
+            t.Errorf("Join(Lines(%q)) = %q", s, got)
+        }
+    }
+}
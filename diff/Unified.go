@@ -0,0 +1,75 @@
+package diff
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Unified renders the changes from one content to another as a unified
+// diff with the given number of context lines, empty when they are equal.
+func Unified(fromName, toName string, from, to []byte, context int) string {
+    ops := Compute(Lines(from), Lines(to))
+
+    // Line numbers in from and to before each op.
+    aLine := make([]int, len(ops)+1)
+    bLine := make([]int, len(ops)+1)
+    var changes []int
+    for i, op := range ops {
+        aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+        if op.Kind != Insert {
+            aLine[i+1]++
+        }
+        if op.Kind != Delete {
+            bLine[i+1]++
+        }
+        if op.Kind != Equal {
+            changes = append(changes, i)
+        }
+    }
+    if len(changes) == 0 {
+        return ""
+    }
+
+    var out strings.Builder
+    fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+    for n := 0; n < len(changes); {
+        start := max(changes[n]-context, 0)
+        end := changes[n] + context + 1
+        n++
+        for n < len(changes) && changes[n]-context <= end {
+            end = changes[n] + context + 1
+            n++
+        }
+        end = min(end, len(ops))
+
+        fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+            hunkRange(aLine[start], aLine[end]-aLine[start]),
+            hunkRange(bLine[start], bLine[end]-bLine[start]))
+        for _, op := range ops[start:end] {
+            prefix := " "
+            switch op.Kind {
+            case Delete:
+                prefix = "-"
+            case Insert:
+                prefix = "+"
+            }
+            out.WriteString(prefix + op.Line)
+            if !strings.HasSuffix(op.Line, "\n") {
+                out.WriteString("\n\\ No newline at end of file\n")
+            }
+        }
+    }
+    return out.String()
+}
+
+// hunkRange is the start,count of a hunk header. Line numbers start at 1,
+// an empty range refers to the line before it.
+func hunkRange(start, count int) string {
+    if count == 0 {
+        return fmt.Sprintf("%d,0", start)
+    }
+    if count == 1 {
+        return fmt.Sprintf("%d", start+1)
+    }
+    return fmt.Sprintf("%d,%d", start+1, count)
+}
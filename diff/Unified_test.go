@@ -0,0 +1,34 @@
+package diff
+
+import (
+    "testing"
+)
+
+func TestUnified(t *testing.T) {
+    from := "a\nb\nc\nd\ne\nf\ng\nh\ni\n"
+    tests := []struct {
+        name string
+        to   string
+        want string
+    }{
+        {"equal", from, ""},
+        {"change", "a\nb\nc\nD\ne\nf\ng\nh\ni\n",
+            "--- old\n+++ new\n@@ -2,5 +2,5 @@\n b\n c\n-d\n+D\n e\n f\n"},
+        {"two hunks", "A\nb\nc\nd\ne\nf\ng\nh\nI\n",
+            "--- old\n+++ new\n@@ -1,3 +1,3 @@\n-a\n+A\n b\n c\n@@ -7,3 +7,3 @@\n g\n h\n-i\n+I\n"},
+        {"append", from + "j\n",
+            "--- old\n+++ new\n@@ -8,2 +8,3 @@\n h\n i\n+j\n"},
+        {"no newline", "a\nb\nc\nd\ne\nf\ng\nh\ni",
+            "--- old\n+++ new\n@@ -7,3 +7,3 @@\n g\n h\n-i\n+i\n\\ No newline at end of file\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := Unified("old", "new", []byte(from), []byte(tt.to), 2); got != tt.want {
+                t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+            }
+        })
+    }
+    if got := Unified("old", "new", nil, []byte("a\n"), 3); got != "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n" {
+        t.Errorf("from empty: %q", got)
+    }
+}
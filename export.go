@@ -0,0 +1,50 @@
+package main
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "path/filepath"
+    "strings"
+    "time"
+    "crontab-tui/parser"
+    "crontab-tui/source"
+)
+
+// exportRuns is how many next fire times an export lists per job.
+const exportRuns = 5
+
+// writeResult writes result as json or yaml.
+func writeResult(w io.Writer, result *parser.Result, format string, runs int) error {
+    return writeResultAt(w, result, format, time.Now(), runs)
+}
+
+// writeResultAt is writeResult with the next runs after now, in its
+// location.
+func writeResultAt(w io.Writer, result *parser.Result, format string, now time.Time, runs int) error {
+    switch format {
+    case "json":
+        return result.WriteJSON(w, now, runs)
+    case "yaml":
+        return result.WriteYAML(w, now, runs)
+    }
+    return fmt.Errorf("unknown format %q, use json or yaml", format)
+}
+
+// exportFormat picks the format from a file name.
+func exportFormat(path string) string {
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".yaml", ".yml":
+        return "yaml"
+    }
+    return "json"
+}
+
+// ExportResult writes result to path in the format its extension names.
+func ExportResult(result *parser.Result, path string) error {
+    var buf bytes.Buffer
+    if err := writeResult(&buf, result, exportFormat(path), exportRuns); err != nil {
+        return err
+    }
+    return source.WriteFileAtomic(path, buf.Bytes())
+}
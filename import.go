@@ -0,0 +1,182 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+    "crontab-tui/parser"
+    "crontab-tui/utils"
+)
+
+// importMarker is the comment above every job an import added. Replacing
+// only ever removes jobs carrying it.
+const importMarker = "# crontab-tui: managed"
+
+// ImportPlan is a validated job list to apply to a crontab.
+type ImportPlan struct {
+    Path    string
+    Jobs    []parser.JobSpec
+    Replace bool
+    // Force lets an empty list replace, removing every managed job.
+    Force   bool
+    Added   int
+    Removed int
+}
+
+func (plan *ImportPlan) mode() string {
+    if plan.Replace {
+        return "replace"
+    }
+    return "merge"
+}
+
+func (plan *ImportPlan) action() string {
+    return fmt.Sprintf("import %s (%s)", plan.Path, plan.mode())
+}
+
+// ReadImport reads and validates the job list in path for a crontab of
+// the given format. The file is read as fileFormat, or as guessed from its
+// name when that is empty.
+func ReadImport(path string, fileFormat string, format parser.Format) (*ImportPlan, error) {
+    if fileFormat == "" {
+        fileFormat = exportFormat(path)
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    jobs, err := parser.ReadJobList(data, fileFormat)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %v", path, err)
+    }
+    if err := validateJobList(jobs, format); err != nil {
+        return nil, err
+    }
+    return &ImportPlan{Path: path, Jobs: jobs}, nil
+}
+
+// errInvalidJobs marks validation failures of a job list.
+var errInvalidJobs = errors.New("invalid job list")
+
+// validateJobList applies the checks of the add popup to every entry.
+func validateJobList(jobs []parser.JobSpec, format parser.Format) error {
+    var problems []string
+    for i, job := range jobs {
+        if err := utils.ValidateScheduleStrict(job.Schedule); err != nil {
+            problems = append(problems, fmt.Sprintf("job %d: schedule: %v", i+1, err))
+            continue
+        }
+        if format == parser.SystemFormat {
+            if err := utils.ValidateUser(job.User); err != nil {
+                problems = append(problems, fmt.Sprintf("job %d: user: %v", i+1, err))
+            }
+        } else if job.User != "" {
+            problems = append(problems, fmt.Sprintf("job %d: user %q given for a crontab without a user column", i+1, job.User))
+        }
+        if err := utils.ValidateCommand(job.Command); err != nil {
+            problems = append(problems, fmt.Sprintf("job %d: command: %v", i+1, err))
+        }
+    }
+    if len(problems) > 0 {
+        return fmt.Errorf("%w: %s", errInvalidJobs, strings.Join(problems, "; "))
+    }
+    return nil
+}
+
+func jobKey(schedule []string, user string, command string, enabled bool, zone string) string {
+    return fmt.Sprintf("%t %s %s", enabled, zone, parser.FormatJobLine(schedule, user, command))
+}
+
+// lineZones returns the CRON_TZ in effect on every line of doc and at its
+// end, where Apply adds jobs.
+func lineZones(doc *parser.Document) ([]string, string) {
+    zones := make([]string, len(doc.Lines))
+    zone := ""
+    for i, line := range doc.Lines {
+        if line.Kind == parser.LineEnv && line.Env.Name == parser.ZoneVariable {
+            zone = line.Env.Value
+        }
+        zones[i] = zone
+    }
+    return zones, zone
+}
+
+func describeZone(zone string) string {
+    if zone == "" {
+        return "the system's time zone"
+    }
+    return parser.ZoneVariable + "=" + zone
+}
+
+// errEmptyReplace refuses to let an empty list, more likely a mistake than
+// a wish, remove every managed job.
+var errEmptyReplace = errors.New("the job list is empty, replacing with it would remove every managed job")
+
+// Apply adds the jobs missing from doc, each below the marker comment. In
+// replace mode marked jobs that are not in the list are removed as well,
+// so that the managed jobs match it exactly.
+func (plan *ImportPlan) Apply(doc *parser.Document) error {
+    if plan.Replace && len(plan.Jobs) == 0 && !plan.Force {
+        return errEmptyReplace
+    }
+    zones, end := lineZones(doc)
+    wanted := map[string]bool{}
+    for _, job := range plan.Jobs {
+        wanted[jobKey(job.Schedule, job.User, job.Command, job.Enabled, job.Timezone)] = true
+    }
+    plan.Added, plan.Removed = 0, 0
+
+    present := map[string]bool{}
+    // Backwards, so that deleting keeps the line numbers still to visit.
+    for i := len(doc.Lines) - 1; i >= 0; i-- {
+        line := doc.Lines[i]
+        if line.Kind != parser.LineJob {
+            continue
+        }
+        job := line.Job
+        key := jobKey(job.Schedule, job.User, job.Command, !job.Disabled, zones[i])
+        managed := i > 0 && strings.TrimSpace(doc.Lines[i-1].Text) == importMarker
+        if managed && plan.Replace && !wanted[key] {
+            if err := doc.DeleteLine(line.Number); err != nil {
+                return err
+            }
+            if err := doc.DeleteLine(line.Number - 1); err != nil {
+                return err
+            }
+            plan.Removed++
+            i--
+            continue
+        }
+        present[key] = true
+    }
+
+    // Added jobs run in the zone in effect at the end of the file. Rather
+    // than change it for the jobs below, refuse those meant for another.
+    var problems []string
+    for i, job := range plan.Jobs {
+        key := jobKey(job.Schedule, job.User, job.Command, job.Enabled, job.Timezone)
+        if !present[key] && job.Timezone != end {
+            problems = append(problems, fmt.Sprintf("job %d runs in %s but jobs added at the end of the crontab run in %s, add a %s=%s line first",
+                i+1, describeZone(job.Timezone), describeZone(end), parser.ZoneVariable, job.Timezone))
+        }
+    }
+    if len(problems) > 0 {
+        return fmt.Errorf("%w: %s", errInvalidJobs, strings.Join(problems, "; "))
+    }
+    for _, job := range plan.Jobs {
+        key := jobKey(job.Schedule, job.User, job.Command, job.Enabled, job.Timezone)
+        if present[key] {
+            continue
+        }
+        present[key] = true
+        doc.AppendLine(importMarker)
+        doc.AppendLine(job.Line())
+        plan.Added++
+    }
+    return nil
+}
+
+func (plan *ImportPlan) summary() string {
+    return fmt.Sprintf("%d job(s) added, %d removed", plan.Added, plan.Removed)
+}
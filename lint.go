@@ -0,0 +1,56 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "crontab-tui/lint"
+    "crontab-tui/parser"
+    "crontab-tui/source"
+)
+
+// lintSources checks every source as it is shown, with staged changes
+// applied, and tags the diagnostics with their origin. Sources that cannot
+// be read are returned as errors.
+func lintSources(sources []source.Source) ([]lint.Diagnostic, []error) {
+    var diagnostics []lint.Diagnostic
+    var errs []error
+    for _, src := range sources {
+        data, err := src.Read()
+        if err != nil {
+            errs = append(errs, fmt.Errorf("%s: %v", src.Name(), err))
+            continue
+        }
+        if stage != nil && stage.Src == src {
+            data = stage.Data
+        }
+        origin := source.Origin(src)
+        for _, d := range lint.Check(parser.ParseDocumentFormat(data, src.Format())) {
+            d.Source = origin
+            diagnostics = append(diagnostics, d)
+        }
+    }
+    return diagnostics, errs
+}
+
+func writeDiagnostics(w io.Writer, diagnostics []lint.Diagnostic, format string) error {
+    if format == "json" {
+        if diagnostics == nil {
+            diagnostics = []lint.Diagnostic{}
+        }
+        encoder := json.NewEncoder(w)
+        encoder.SetIndent("", "  ")
+        encoder.SetEscapeHTML(false)
+        return encoder.Encode(diagnostics)
+    }
+    for _, d := range diagnostics {
+        fmt.Fprintf(w, "%s:%s\n", d.Source, d)
+        if d.Fix != nil {
+            fmt.Fprintf(w, "    fix: %s\n", d.Fix.Description)
+            if d.Fix.Line != "" {
+                fmt.Fprintf(w, "         %s\n", d.Fix.Line)
+            }
+        }
+    }
+    return nil
+}
@@ -0,0 +1,98 @@
+package lint
+
+import (
+    "fmt"
+    "sort"
+    "time"
+    "crontab-tui/parser"
+)
+
+type Severity int
+
+const (
+    Info Severity = iota
+    Warning
+    Error
+)
+
+func (s Severity) String() string {
+    switch s {
+    case Error:
+        return "error"
+    case Warning:
+        return "warning"
+    }
+    return "info"
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+    return []byte(s.String()), nil
+}
+
+// ParseSeverity is the inverse of Severity.String.
+func ParseSeverity(name string) (Severity, error) {
+    for _, s := range []Severity{Info, Warning, Error} {
+        if s.String() == name {
+            return s, nil
+        }
+    }
+    return Info, fmt.Errorf("unknown severity %q, use info, warning or error", name)
+}
+
+// Fix is a suggested way to resolve a diagnostic. Line, when not empty, is
+// a replacement for the whole line that can be applied as is.
+type Fix struct {
+    Description string `json:"description"`
+    Line        string `json:"line,omitempty"`
+}
+
+// Diagnostic is one finding. Line and Column count from 1, Column being
+// the byte offset of what the message is about. Source is left for the
+// caller to fill in.
+type Diagnostic struct {
+    Source   string   `json:"source,omitempty"`
+    Line     int      `json:"line"`
+    Column   int      `json:"column"`
+    Severity Severity `json:"severity"`
+    Rule     string   `json:"rule"`
+    Message  string   `json:"message"`
+    Fix      *Fix     `json:"fix,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+    return fmt.Sprintf("%d:%d: %s: %s [%s]", d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// Check runs every rule on a crontab and returns the diagnostics ordered
+// by position.
+func Check(doc *parser.Document) []Diagnostic {
+    return CheckAt(doc, time.Now())
+}
+
+// CheckAt is Check with the time schedules are evaluated from. Jobs
+// without a CRON_TZ are evaluated in its location.
+func CheckAt(doc *parser.Document, now time.Time) []Diagnostic {
+    c := &checker{doc: doc, now: now, location: now.Location(), jobs: map[string]*parser.Line{}, commands: map[string][]*parser.Line{}}
+    for _, line := range doc.Lines {
+        c.line(line)
+    }
+    sort.SliceStable(c.diagnostics, func(i, j int) bool {
+        a, b := c.diagnostics[i], c.diagnostics[j]
+        if a.Line != b.Line {
+            return a.Line < b.Line
+        }
+        return a.Column < b.Column
+    })
+    return c.diagnostics
+}
+
+// Count returns how many diagnostics have at least the given severity.
+func Count(diagnostics []Diagnostic, min Severity) int {
+    n := 0
+    for _, d := range diagnostics {
+        if d.Severity >= min {
+            n++
+        }
+    }
+    return n
+}
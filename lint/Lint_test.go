@@ -0,0 +1,75 @@
+package lint
+
+import (
+    "testing"
+    "time"
+    _ "time/tzdata"
+    "crontab-tui/parser"
+)
+
+func TestCheck(t *testing.T) {
+    in := `# header
+61 * * * * /bin/true
+@fortnightly /bin/true
+0 0 30 2 * /bin/true
+0 9 1-7 * MON /bin/sh -c date
+0 3 * * * /bin/date +%F
+*/5 * * * * scripts/poll.sh
+0 4 * * * no-such-tool-for-lint --now
+# 0 4 * * * no-such-tool-for-lint --disabled
+*/10 * * * * /bin/sync
+*/10 * * * * /bin/sync
+0 * * * * /bin/sync
+PATH=/usr/local/bin:/usr/bin:/bin
+0 5 * * * no-such-tool-for-lint --later
+5 * * * * cd /tmp && /bin/true
+CRON_TZ=America/New_York
+*/10 * * * * /bin/sync
+CRON_TZ=Mars/Olympus
+0 6 * * * /bin/echo ok \%done%line two
+`
+    doc := parser.ParseDocument([]byte(in))
+    got := CheckAt(doc, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+    want := []struct {
+        line, column int
+        severity     Severity
+        rule         string
+    }{
+        {2, 1, Error, RuleInvalidField},
+        {3, 1, Error, RuleInvalidField},
+        {4, 5, Error, RuleNeverFires},
+        {5, 5, Warning, RuleDomDowOr},
+        {6, 22, Warning, RuleUnescapedPercent},
+        {7, 13, Warning, RuleRelativePath},
+        {8, 11, Warning, RuleMissingPath},
+        {11, 1, Warning, RuleDuplicate},
+        {12, 1, Info, RuleOverlap},
+        {18, 9, Warning, RuleUnknownZone},
+        {19, 30, Info, RuleUnescapedPercent},
+    }
+    if len(got) != len(want) {
+        for _, d := range got {
+            t.Log(d)
+        }
+        t.Fatalf("got %d diagnostics, want %d", len(got), len(want))
+    }
+    for i, w := range want {
+        d := got[i]
+        if d.Line != w.line || d.Column != w.column || d.Severity != w.severity || d.Rule != w.rule {
+            t.Errorf("diagnostic %d = %v, want %d:%d: %s [%s]", i, d, w.line, w.column, w.severity, w.rule)
+        }
+    }
+
+    fix := got[4].Fix
+    if fix == nil || fix.Line != `0 3 * * * /bin/date +\%F` {
+        t.Errorf("percent fix %+v", fix)
+    }
+    fix = got[10].Fix
+    if fix == nil || fix.Line != `0 6 * * * /bin/echo ok \%done\%line two` {
+        t.Errorf("percent fix %+v", fix)
+    }
+    if got[8].Message != "line 10 runs the same command, both start it at 2026-01-01 01:00" {
+        t.Errorf("overlap message %q", got[8].Message)
+    }
+}
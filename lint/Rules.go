@@ -0,0 +1,253 @@
+package lint
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+    "crontab-tui/parser"
+)
+
+// Rule IDs, stable for scripts filtering the output.
+const (
+    RuleInvalidField     = "invalid-field"
+    RuleInvalidLine      = "invalid-line"
+    RuleDomDowOr         = "dom-dow-or"
+    RuleUnescapedPercent = "unescaped-percent"
+    RuleRelativePath     = "relative-path"
+    RuleMissingPath      = "missing-path"
+    RuleNeverFires       = "never-fires"
+    RuleDuplicate        = "duplicate"
+    RuleOverlap          = "overlap"
+    RuleUnknownZone      = "unknown-timezone"
+)
+
+// DefaultPath is the PATH cron runs jobs with unless the crontab sets one.
+const DefaultPath = "/usr/bin:/bin"
+
+// SuggestedPath is offered when a job needs more than DefaultPath.
+const SuggestedPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// shellWords are run by sh itself and need no PATH lookup.
+var shellWords = map[string]bool{
+    ".": true, ":": true, "[": true, "cd": true, "echo": true, "eval": true,
+    "exec": true, "exit": true, "export": true, "false": true, "for": true,
+    "if": true, "printf": true, "read": true, "set": true, "test": true,
+    "true": true, "ulimit": true, "umask": true, "unset": true, "while": true,
+    "case": true, "{": true, "(": true,
+}
+
+// checker walks a document once, keeping what later lines are compared
+// with.
+type checker struct {
+    doc         *parser.Document
+    now         time.Time
+    diagnostics []Diagnostic
+    pathSet     bool
+    // zone is the CRON_TZ in effect, schedules are evaluated in location.
+    zone        string
+    location    *time.Location
+    // jobs maps the canonical form of every enabled job to its first line.
+    jobs map[string]*parser.Line
+    // commands lists the enabled jobs running each command.
+    commands map[string][]*parser.Line
+}
+
+func (c *checker) report(line *parser.Line, column int, severity Severity, rule string, message string, fix *Fix) {
+    c.diagnostics = append(c.diagnostics, Diagnostic{
+        Line:     line.Number,
+        Column:   column + 1,
+        Severity: severity,
+        Rule:     rule,
+        Message:  message,
+        Fix:      fix,
+    })
+}
+
+func (c *checker) line(line *parser.Line) {
+    switch line.Kind {
+    case parser.LineEnv:
+        switch line.Env.Name {
+        case "PATH":
+            c.pathSet = true
+        case parser.ZoneVariable:
+            c.timezone(line)
+        }
+    case parser.LineInvalid:
+        c.invalid(line)
+    case parser.LineJob:
+        if !line.Job.Disabled {
+            c.job(line)
+        }
+    }
+}
+
+// timezone checks a CRON_TZ assignment, cron runs the jobs below an
+// unknown zone in the system's.
+func (c *checker) timezone(line *parser.Line) {
+    c.zone = line.Env.Value
+    c.location = c.now.Location()
+    if c.zone == "" {
+        return
+    }
+    loc, err := parser.LoadZone(c.zone)
+    if err != nil {
+        c.report(line, strings.Index(line.Text, "=")+1, Warning, RuleUnknownZone,
+            fmt.Sprintf("unknown time zone %q, the jobs below run in the system's time zone", c.zone),
+            &Fix{Description: "use a name from the tz database, e.g. Europe/Berlin or UTC"})
+        return
+    }
+    c.location = loc
+}
+
+// fieldColumns returns the offset of every whitespace separated field.
+func fieldColumns(text string) []int {
+    var columns []int
+    inField := false
+    for i, r := range text {
+        space := r == ' ' || r == '\t'
+        if !space && !inField {
+            columns = append(columns, i)
+        }
+        inField = !space
+    }
+    return columns
+}
+
+// invalid points at the schedule field cron would reject, if it is one.
+func (c *checker) invalid(line *parser.Line) {
+    fields := strings.Fields(line.Text)
+    columns := fieldColumns(line.Text)
+    if strings.HasPrefix(fields[0], "@") {
+        if _, err := parser.ParseSchedule(fields[:1]); err != nil {
+            c.report(line, columns[0], Error, RuleInvalidField, err.Error(), nil)
+            return
+        }
+    } else {
+        for i := 0; i < 5 && i < len(fields); i++ {
+            if parser.ValidateScheduleField(i, fields[i]) != nil {
+                c.report(line, columns[i], Error, RuleInvalidField, line.Err.Error(), nil)
+                return
+            }
+        }
+    }
+    c.report(line, columns[0], Error, RuleInvalidLine, line.Err.Error(), nil)
+}
+
+func (c *checker) job(line *parser.Line) {
+    job := line.Job
+    columns := fieldColumns(line.Text)
+    commandField := len(job.Schedule)
+    if c.doc.Format == parser.SystemFormat {
+        commandField++
+    }
+    commandColumn := columns[commandField]
+
+    if len(job.Schedule) == 5 {
+        c.schedule(line, columns)
+    }
+    c.percent(line, commandColumn)
+    c.command(line, commandColumn)
+    c.repeated(line)
+}
+
+func (c *checker) schedule(line *parser.Line, columns []int) {
+    job := line.Job
+    dom, dow := job.Schedule[2], job.Schedule[4]
+    if job.Spec.Next(c.now.In(c.location)).IsZero() {
+        c.report(line, columns[2], Error, RuleNeverFires,
+            fmt.Sprintf("day of month %s never occurs in month %s, the job never runs", dom, job.Schedule[3]),
+            &Fix{Description: "check the day of month and month fields"})
+        return
+    }
+    if !strings.HasPrefix(dom, "*") && !strings.HasPrefix(dow, "*") {
+        c.report(line, columns[2], Warning, RuleDomDowOr,
+            fmt.Sprintf("runs on every day matching day of month %s or day of week %s, not only on days matching both", dom, dow),
+            &Fix{Description: "set one of the two fields to * and test the other in the command, e.g. [ \"$(date +\\%u)\" = 1 ] &&"})
+    }
+}
+
+// percent flags % signs cron turns into newlines, the text after the
+// first one becoming the job's standard input. One that reads like a date
+// or printf conversion is most likely a mistake, others may be meant.
+func (c *checker) percent(line *parser.Line, commandColumn int) {
+    command := line.Text[commandColumn:]
+    escaped := parser.EscapePercent(command)
+    if escaped == command {
+        return
+    }
+    fix := &Fix{Description: "escape it as \\% to keep it in the command", Line: line.Text[:commandColumn] + escaped}
+    if i := parser.UnintendedPercent(command); i >= 0 {
+        c.report(line, commandColumn+i, Warning, RuleUnescapedPercent,
+            "the % looks meant for the command, but cron ends the command there and passes the rest as standard input", fix)
+        return
+    }
+    // EscapePercent only inserts, so the texts part at the first %.
+    i := 0
+    for command[i] == escaped[i] {
+        i++
+    }
+    c.report(line, commandColumn+i, Info, RuleUnescapedPercent,
+        "cron ends the command at an unescaped %, the rest is passed as standard input", fix)
+}
+
+// command checks how the program the job starts is found.
+func (c *checker) command(line *parser.Line, commandColumn int) {
+    program := ""
+    for _, word := range strings.Fields(line.Job.Command) {
+        // Skip variable assignments in front of the command.
+        if !strings.Contains(word, "=") {
+            program = word
+            break
+        }
+    }
+    switch {
+    case program == "" || shellWords[program]:
+    case strings.HasPrefix(program, "/") || strings.HasPrefix(program, "~") || strings.HasPrefix(program, "$"):
+    case strings.Contains(program, "/"):
+        c.report(line, commandColumn, Warning, RuleRelativePath,
+            fmt.Sprintf("%s is relative to the home directory cron starts the job in", program),
+            &Fix{Description: "use an absolute path"})
+    case !c.pathSet && !inPath(program, DefaultPath):
+        c.report(line, commandColumn, Warning, RuleMissingPath,
+            fmt.Sprintf("%s is not in cron's default PATH (%s) and the crontab sets none", program, DefaultPath),
+            &Fix{Description: "add PATH=" + SuggestedPath + " above the job, or use an absolute path"})
+    }
+}
+
+func inPath(program string, path string) bool {
+    for _, dir := range filepath.SplitList(path) {
+        if info, err := os.Stat(filepath.Join(dir, program)); err == nil && !info.IsDir() {
+            return true
+        }
+    }
+    return false
+}
+
+// repeated compares the job with the ones before it: the same job twice
+// runs twice, the same command on different schedules may run twice at
+// once.
+func (c *checker) repeated(line *parser.Line) {
+    job := line.Job
+    // Jobs in different zones are compared by neither rule.
+    key := fmt.Sprintf("%s %s %s", c.zone, job.User, strings.TrimSpace(job.Command))
+    canonical := fmt.Sprintf("%+v %s", *job.Spec, key)
+    if first, ok := c.jobs[canonical]; ok {
+        c.report(line, 0, Warning, RuleDuplicate,
+            fmt.Sprintf("same job as line %d, it runs twice", first.Number),
+            &Fix{Description: "remove one of the two lines"})
+        return
+    }
+    c.jobs[canonical] = line
+
+    for _, other := range c.commands[key] {
+        if at := job.Spec.Overlap(other.Job.Spec, c.now.In(c.location)); !at.IsZero() {
+            c.report(line, 0, Info, RuleOverlap,
+                fmt.Sprintf("line %d runs the same command, both start it at %s", other.Number, at.Format("2006-01-02 15:04")),
+                &Fix{Description: "merge the schedules or make the command skip a run already in progress, e.g. with flock -n"})
+            break
+        }
+    }
+    c.commands[key] = append(c.commands[key], line)
+}
@@ -8,18 +8,84 @@ import (
     "os"
     "time"
     "strings"
+    "context"
+    "flag"
+    "crontab-tui/diff"
+    "crontab-tui/lint"
     "crontab-tui/parser"
+    "crontab-tui/runner"
+    "crontab-tui/source"
     "crontab-tui/utils"
+    // Zones work on hosts without /usr/share/zoneinfo as well.
+    _ "time/tzdata"
 )
 
 var crontablistPanel *ui.CrontabListPanel
 var descriptionPanel *ui.DescriptionPanel
 var addCommandPanel  *ui.AddCommandPanel
+var editCommandPanel *ui.EditCommandPanel
 var statusPanel      *ui.StatusPanel
+var confirmPanel     *ui.ConfirmPanel
+var backupsPanel     *ui.BackupsPanel
+var conflictPanel    *ui.ConflictPanel
+var diffPanel        *ui.DiffPanel
+var exportPanel      *ui.PathPanel
+var importPanel      *ui.PathPanel
+var diagnosticsPanel *ui.DiagnosticsPanel
+var envPanel         *ui.EnvPanel
+var envEditPanel     *ui.PathPanel
+var zonePanel        *ui.PathPanel
+var outputPanel      *ui.OutputPanel
+var envComparePanel  *ui.EnvComparePanel
+// activeRun is the job started with r, until it exits.
+var activeRun        *runner.Run
+var activeConflict   *ConflictError
+// reloadPending is set when the crontab changed while a popup was open,
+// the list is reloaded once the user is back to it.
+var reloadPending    bool
+var confirmAction    func(g *gocui.Gui) error
+// percentWarned is the add or edit input a % warning was shown for, Enter
+// on it again saves it as typed.
+var percentWarned    string
 var cursor *ui.Cursor
-var CRON_FILE = "./example.txt"
+var crontabSources []source.Source
+var crontabSource  source.Source
+var sourceIndex    int
+var watcher        source.Watcher
+// aggregate is set for --all, the list then merges every source.
+var aggregate      bool
 
 func main() {
+    if len(os.Args) > 1 {
+        if c, ok := findCommand(os.Args[1]); ok {
+            os.Exit(runCommand(c, os.Args[2:], os.Stdout, os.Stderr))
+        }
+    }
+    opts, err := parseFlags(os.Args[1:], os.Stderr)
+    if err == flag.ErrHelp {
+        os.Exit(0)
+    }
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "crontab-tui: %v\n", err)
+        os.Exit(2)
+    }
+    crontabSources, err = opts.sources()
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "crontab-tui: %v\n", err)
+        os.Exit(1)
+    }
+    crontabSource = crontabSources[0]
+    aggregate = opts.all
+    staging = opts.staged && !aggregate
+
+    // Fail before the terminal is taken over if the crontab is unreadable.
+    // The aggregate view lists whatever it could read instead.
+    jobs, loadErr := loadView()
+    if loadErr != nil && jobs == nil {
+        fmt.Fprintf(os.Stderr, "Error %v\n", loadErr)
+        os.Exit(1)
+    }
+
     g, err := gocui.NewGui(gocui.OutputNormal)
     if err != nil {
         log.Panicln(err)
@@ -30,43 +96,62 @@ func main() {
     crontablistPanel, _ = ui.NewCrontabListPanel()
     descriptionPanel, _ = ui.NewDescriptionPanel()
     addCommandPanel,  _ = ui.NewAddCommandPanel()
+    editCommandPanel, _ = ui.NewEditCommandPanel()
     statusPanel, _      = ui.NewStatusPanel()
+    confirmPanel, _     = ui.NewConfirmPanel()
+    backupsPanel, _     = ui.NewBackupsPanel()
+    conflictPanel, _    = ui.NewConflictPanel()
+    diffPanel, _        = ui.NewDiffPanel()
+    exportPanel, _      = ui.NewPathPanel("export", "Export listed jobs to (.json or .yaml)")
+    importPanel, _      = ui.NewPathPanel("import", "Import jobs from (.json or .yaml)")
+    diagnosticsPanel, _ = ui.NewDiagnosticsPanel()
+    envPanel, _         = ui.NewEnvPanel()
+    envEditPanel, _     = ui.NewPathPanel("envedit", "NAME=value")
+    zonePanel, _        = ui.NewPathPanel("zone", "Show times in (tz database name, Local or UTC)")
+    outputPanel, _      = ui.NewOutputPanel()
+    envComparePanel, _  = ui.NewEnvComparePanel()
     cursor = &ui.Cursor{}
     
-    //const path = "./example.txt"
-    jobs, err := parser.ParseCrontab(CRON_FILE)
-    if err != nil {
-        fmt.Fprintf(os.Stderr, "Error %v\n", err)
-        os.Exit(1)
-    }
-
     crontablistPanel.CrontabList = jobs
+    crontablistPanel.SourceName = sourceLabel()
+    setDisplayZone(opts.displayZone)
     crontablistPanel.DrawView(g)
     descriptionPanel.DrawView(g)
     statusPanel.DrawView(g)
-    descriptionPanel.DrawText(g, &crontablistPanel.CrontabList.CronJobs[0])
+    drawSelectedDescription(g)
+    if loadErr != nil {
+        statusPanel.SetError(g, loadErr)
+    }
+    showStageMode(g)
     //fmt.Printf("Parsed %d job(s) from %s\n\n", len(jobs.CronJobs), path)
 
-    parser.WatchCronFile(CRON_FILE, time.Second, func() {
-        g.Update(func(gui *gocui.Gui) error {
-            new_jobs, err := parser.ParseCrontab(CRON_FILE)
-            if err != nil {
-                return err
-            }
-            crontablistPanel.CrontabList = new_jobs
-            crontablistPanel.DrawView(gui)
-            descriptionPanel.DrawView(gui)
-            descriptionPanel.DrawText(gui, &crontablistPanel.CrontabList.CronJobs[0])
-            return nil
-        }) 
-    })
+    if err := watchSource(g); err != nil {
+        log.Panicln(err)
+    }
+    defer func() {
+        watcher.Stop()
+    }()
+
+    // Keep the next run column current.
+    go func() {
+        ticker := time.NewTicker(time.Minute)
+        defer ticker.Stop()
+        for range ticker.C {
+            g.Update(refreshPanels)
+        }
+    }()
 
 
     g.SetCurrentView(crontablistPanel.ViewName)
 
     keybindings(g)
     
-    if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
+    err = g.MainLoop()
+    if activeRun != nil {
+        activeRun.Cancel()
+        activeRun.Wait()
+    }
+    if err != nil && err != gocui.ErrQuit {
         log.Panicln(err)
     }
 
@@ -101,6 +186,188 @@ func render(g *gocui.Gui) {
     descriptionPanel.DrawView(g)
 }
 
+// watchSource (re)starts watching the current source.
+func watchSource(g *gocui.Gui) error {
+    if watcher != nil {
+        watcher.Stop()
+    }
+    if aggregate {
+        watcher = source.WatchAll(context.Background(), crontabSources, 2*time.Second, func() {
+            g.Update(externalChange)
+        })
+        return nil
+    }
+    src := crontabSource
+    w, err := source.Watch(context.Background(), src, time.Second, func() {
+        g.Update(func(gui *gocui.Gui) error {
+            if src != crontabSource {
+                return nil
+            }
+            return externalChange(gui)
+        }) 
+    })
+    if err != nil {
+        return err
+    }
+    watcher = w
+    return nil
+}
+
+// externalChange reloads the list after the crontab changed on disk,
+// unless a popup is open: an edit in progress keeps the content it was
+// started on, and saving it then detects the conflict.
+func externalChange(g *gocui.Gui) error {
+    if v := g.CurrentView(); v != nil && v.Name() != crontablistPanel.ViewName {
+        reloadPending = true
+        if !aggregate && matchesBaseline(crontabSource) {
+            return nil
+        }
+        return statusPanel.SetMessage(g, "The crontab changed on disk, reloading when you are done")
+    }
+    return reloadCrontab(g)
+}
+
+// returnToList focuses the list after a popup closed and applies a reload
+// held back while it was open.
+func returnToList(g *gocui.Gui) error {
+    g.SetCurrentView(crontablistPanel.ViewName)
+    if !reloadPending {
+        return nil
+    }
+    reloadPending = false
+    return reloadCrontab(g)
+}
+
+func reloadCrontab(g *gocui.Gui) error {
+    new_jobs, err := loadView()
+    if new_jobs != nil {
+        crontablistPanel.CrontabList = new_jobs
+        crontablistPanel.SourceName = sourceLabel()
+    }
+    if err != nil {
+        statusPanel.SetError(g, err)
+    }
+    return refreshPanels(g)
+}
+
+func sourceLabel() string {
+    if aggregate {
+        if crontablistPanel.Filter == "" {
+            return fmt.Sprintf("all sources (%d)", len(crontabSources))
+        }
+        return crontablistPanel.Filter
+    }
+    if len(crontabSources) == 1 {
+        return crontabSource.Name()
+    }
+    return fmt.Sprintf("%s (%d/%d)", crontabSource.Name(), sourceIndex+1, len(crontabSources))
+}
+
+// nextSource cycles through the crontabs selected on the command line, or
+// through the source filters of the aggregate view.
+func nextSource(g *gocui.Gui, _ *gocui.View) error {
+    if aggregate {
+        return nextSourceFilter(g)
+    }
+    if len(crontabSources) < 2 {
+        return nil
+    }
+    if stage.Len() > 0 {
+        return statusPanel.SetError(g, fmt.Errorf("save (w) or discard (x) the staged changes first"))
+    }
+    sourceIndex = (sourceIndex + 1) % len(crontabSources)
+    crontabSource = crontabSources[sourceIndex]
+    if v, err := g.View(crontablistPanel.ViewName); err == nil {
+        cursor.MoveToFirst(g, v)
+    }
+    if err := watchSource(g); err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    return reloadCrontab(g)
+}
+
+// nextSourceFilter goes from all sources to each source having jobs in
+// turn and back.
+func nextSourceFilter(g *gocui.Gui) error {
+    filters := append([]string{""}, crontablistPanel.CrontabList.Sources()...)
+    next := 0
+    for i, filter := range filters {
+        if filter == crontablistPanel.Filter {
+            next = (i + 1) % len(filters)
+        }
+    }
+    crontablistPanel.Filter = filters[next]
+    crontablistPanel.SourceName = sourceLabel()
+    if v, err := g.View(crontablistPanel.ViewName); err == nil {
+        cursor.MoveToFirst(g, v)
+    }
+    return refreshPanels(g)
+}
+
+// loadView reads what the list shows: the current source, or every source
+// merged in the aggregate view. The latter returns a result along with an
+// error when only some sources could be read.
+func loadView() (*parser.Result, error) {
+    if aggregate {
+        result, errs := source.LoadAll(crontabSources)
+        if len(errs) > 0 {
+            return result, fmt.Errorf("%d source(s) unreadable, first: %v", len(errs), errs[0])
+        }
+        return result, nil
+    }
+    data, err := crontabSource.Read()
+    if err != nil {
+        return nil, err
+    }
+    setBaseline(crontabSource, data)
+    if stage != nil && stage.Src == crontabSource {
+        // Show the staged changes, saving merges them with the file.
+        data = stage.Data
+    }
+    return source.Parse(crontabSource, data), nil
+}
+
+// requireWritable reports an error on the status line in views where jobs
+// cannot be changed.
+func requireWritable(g *gocui.Gui) bool {
+    if aggregate {
+        statusPanel.SetError(g, fmt.Errorf("the aggregate view is read-only, open a single crontab to edit it"))
+        return false
+    }
+    return true
+}
+
+func refreshPanels(g *gocui.Gui) error {
+    if err := crontablistPanel.Refresh(g); err != nil {
+        return err
+    }
+    return drawSelectedDescription(g)
+}
+
+func selectedJob(g *gocui.Gui) *parser.CronJob {
+    list := crontablistPanel.Visible()
+    if list == nil {
+        return nil
+    }
+    yOffset, yCurrent, err := cursor.FindPosition(g, crontablistPanel.ViewName)
+    if err != nil {
+        return nil
+    }
+    idx := yOffset + yCurrent
+    if idx < 0 || idx >= len(list.CronJobs) {
+        return nil
+    }
+    return &list.CronJobs[idx]
+}
+
+func drawSelectedDescription(g *gocui.Gui) error {
+    job := selectedJob(g)
+    if job == nil {
+        return nil
+    }
+    return descriptionPanel.DrawText(g, job)
+}
+
 func keybindings(g *gocui.Gui) {
     if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
 		log.Panicln(err)
@@ -124,6 +391,222 @@ func keybindings(g *gocui.Gui) {
     if err := g.SetKeybinding(addCommandPanel.ViewName, gocui.KeyEsc, gocui.ModNone, clearErrorOnType); err != nil {
         log.Panicln(err)
     }
+    if err := g.SetKeybinding(addCommandPanel.ViewName, gocui.KeyTab, gocui.ModNone, escapeInputPercent); err != nil {
+        log.Panicln(err)
+    }
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'e', gocui.ModNone, drawEditEditor); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(editCommandPanel.ViewName, gocui.KeyEnter, gocui.ModNone, editCrontabJob); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(editCommandPanel.ViewName, gocui.KeyEsc, gocui.ModNone, cancelEdit); err != nil {
+	    log.Panicln(err)
+	}
+    if err := g.SetKeybinding(editCommandPanel.ViewName, gocui.KeyTab, gocui.ModNone, escapeInputPercent); err != nil {
+        log.Panicln(err)
+    }
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'd', gocui.ModNone, confirmDelete); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 't', gocui.ModNone, toggleJob); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, gocui.KeyTab, gocui.ModNone, nextSource); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'n', gocui.ModNone, nextInvalid); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'u', gocui.ModNone, undo); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'b', gocui.ModNone, drawBackups); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(backupsPanel.ViewName, 'k', gocui.ModNone, cursorMovement(-1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(backupsPanel.ViewName, 'j', gocui.ModNone, cursorMovement(1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(backupsPanel.ViewName, gocui.KeyEnter, gocui.ModNone, confirmRestore); err != nil {
+	    log.Panicln(err)
+	}
+	for _, key := range []interface{}{'q', gocui.KeyEsc} {
+	    if err := g.SetKeybinding(backupsPanel.ViewName, key, gocui.ModNone, closeBackups); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	if err := g.SetKeybinding(conflictPanel.ViewName, 'j', gocui.ModNone, conflictMove(1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(conflictPanel.ViewName, 'k', gocui.ModNone, conflictMove(-1)); err != nil {
+	    log.Panicln(err)
+	}
+	for key, choice := range map[rune]diff.Choice{'1': diff.ChooseMine, '2': diff.ChooseTheirs, '3': diff.ChooseBoth} {
+	    if err := g.SetKeybinding(conflictPanel.ViewName, key, gocui.ModNone, conflictChoose(choice)); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	if err := g.SetKeybinding(conflictPanel.ViewName, gocui.KeyEnter, gocui.ModNone, saveMerge); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(conflictPanel.ViewName, 'm', gocui.ModNone, keepMine); err != nil {
+	    log.Panicln(err)
+	}
+	for _, key := range []interface{}{'t', gocui.KeyEsc} {
+	    if err := g.SetKeybinding(conflictPanel.ViewName, key, gocui.ModNone, takeTheirs); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'E', gocui.ModNone, drawExportEditor); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(exportPanel.ViewName, gocui.KeyEnter, gocui.ModNone, exportJobs); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(exportPanel.ViewName, gocui.KeyEsc, gocui.ModNone, closeExport); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'I', gocui.ModNone, drawImportEditor); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(importPanel.ViewName, gocui.KeyEnter, gocui.ModNone, importJobs); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(importPanel.ViewName, gocui.KeyEsc, gocui.ModNone, closeImport); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(diffPanel.ViewName, 'r', gocui.ModNone, toggleImportMode); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'L', gocui.ModNone, drawDiagnostics); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(diagnosticsPanel.ViewName, 'k', gocui.ModNone, cursorMovement(-1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(diagnosticsPanel.ViewName, 'j', gocui.ModNone, cursorMovement(1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(diagnosticsPanel.ViewName, gocui.KeyEnter, gocui.ModNone, gotoDiagnostic); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(diagnosticsPanel.ViewName, 'f', gocui.ModNone, applyFix); err != nil {
+	    log.Panicln(err)
+	}
+	for _, key := range []interface{}{'q', 'L', gocui.KeyEsc} {
+	    if err := g.SetKeybinding(diagnosticsPanel.ViewName, key, gocui.ModNone, closeDiagnostics); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'v', gocui.ModNone, drawEnv); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(envPanel.ViewName, 'k', gocui.ModNone, cursorMovement(-1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(envPanel.ViewName, 'j', gocui.ModNone, cursorMovement(1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(envPanel.ViewName, 'a', gocui.ModNone, drawEnvAdd); err != nil {
+	    log.Panicln(err)
+	}
+	for _, key := range []interface{}{'e', gocui.KeyEnter} {
+	    if err := g.SetKeybinding(envPanel.ViewName, key, gocui.ModNone, drawEnvEdit); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	if err := g.SetKeybinding(envPanel.ViewName, 'd', gocui.ModNone, confirmEnvDelete); err != nil {
+	    log.Panicln(err)
+	}
+	for _, key := range []interface{}{'q', 'v', gocui.KeyEsc} {
+	    if err := g.SetKeybinding(envPanel.ViewName, key, gocui.ModNone, closeEnv); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	if err := g.SetKeybinding(envEditPanel.ViewName, gocui.KeyEnter, gocui.ModNone, saveEnv); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(envEditPanel.ViewName, gocui.KeyEsc, gocui.ModNone, cancelEnvEdit); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'z', gocui.ModNone, drawZoneEditor); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(zonePanel.ViewName, gocui.KeyEnter, gocui.ModNone, saveZone); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(zonePanel.ViewName, gocui.KeyEsc, gocui.ModNone, closeZone); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'r', gocui.ModNone, confirmRun); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(outputPanel.ViewName, 'j', gocui.ModNone, outputScroll(1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(outputPanel.ViewName, 'k', gocui.ModNone, outputScroll(-1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(outputPanel.ViewName, 'c', gocui.ModNone, cancelRun); err != nil {
+	    log.Panicln(err)
+	}
+	for _, key := range []interface{}{'q', gocui.KeyEsc} {
+	    if err := g.SetKeybinding(outputPanel.ViewName, key, gocui.ModNone, closeOutput); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'C', gocui.ModNone, drawEnvCompare); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(envComparePanel.ViewName, 'j', gocui.ModNone, envCompareScroll(1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(envComparePanel.ViewName, 'k', gocui.ModNone, envCompareScroll(-1)); err != nil {
+	    log.Panicln(err)
+	}
+	for _, key := range []interface{}{'C', 'q', gocui.KeyEsc} {
+	    if err := g.SetKeybinding(envComparePanel.ViewName, key, gocui.ModNone, closeEnvCompare); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'S', gocui.ModNone, toggleStaging); err != nil {
+	    log.Panicln(err)
+	}
+	for _, view := range []string{crontablistPanel.ViewName, diffPanel.ViewName} {
+	    if err := g.SetKeybinding(view, 'w', gocui.ModNone, saveStaged); err != nil {
+	        log.Panicln(err)
+	    }
+	    if err := g.SetKeybinding(view, 'x', gocui.ModNone, confirmDiscard); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	if err := g.SetKeybinding(crontablistPanel.ViewName, 'D', gocui.ModNone, drawStageDiff); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(diffPanel.ViewName, 'j', gocui.ModNone, diffScroll(1)); err != nil {
+	    log.Panicln(err)
+	}
+	if err := g.SetKeybinding(diffPanel.ViewName, 'k', gocui.ModNone, diffScroll(-1)); err != nil {
+	    log.Panicln(err)
+	}
+	for _, key := range []interface{}{'D', 'q', gocui.KeyEsc} {
+	    if err := g.SetKeybinding(diffPanel.ViewName, key, gocui.ModNone, closeDiff); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	for _, key := range []interface{}{'y', gocui.KeyEnter} {
+	    if err := g.SetKeybinding(confirmPanel.ViewName, key, gocui.ModNone, confirm); err != nil {
+	        log.Panicln(err)
+	    }
+	}
+	for _, key := range []interface{}{'n', gocui.KeyEsc} {
+	    if err := g.SetKeybinding(confirmPanel.ViewName, key, gocui.ModNone, cancelConfirm); err != nil {
+	        log.Panicln(err)
+	    }
+	}
 }
 
 func exit(g *gocui.Gui, v *gocui.View) error {
@@ -131,17 +614,23 @@ func exit(g *gocui.Gui, v *gocui.View) error {
 }
 
 func quit(g *gocui.Gui, v *gocui.View) error {
+    if stage.Len() > 0 {
+        message := fmt.Sprintf("%d staged change(s) to %s were not saved.", stage.Len(), stage.Src.Name())
+        return askConfirmation(g, "Quit and discard them?", message, func(g *gocui.Gui) error {
+            return gocui.ErrQuit
+        })
+    }
     return gocui.ErrQuit
 }
 
 func cursorMovement(d int) func(g *gocui.Gui, v *gocui.View) error {
     return func(g *gocui.Gui, v *gocui.View) error {
         cursor.Move(g, v, d, func(yOffset int, yCurrent int) error {
-            if g.CurrentView().Name() == crontablistPanel.ViewName {
-                if yOffset + yCurrent >= len(crontablistPanel.CrontabList.CronJobs) {
-                    return nil
-                }
-                descriptionPanel.DrawText(g, &crontablistPanel.CrontabList.CronJobs[yOffset+yCurrent])
+            switch g.CurrentView().Name() {
+            case crontablistPanel.ViewName:
+                drawSelectedDescription(g)
+            case diagnosticsPanel.ViewName:
+                showSelectedFix(g)
             }
             return nil
         })
@@ -150,6 +639,10 @@ func cursorMovement(d int) func(g *gocui.Gui, v *gocui.View) error {
 }
 
 func drawAddEditor(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    percentWarned = ""
     err := addCommandPanel.DrawView(g)
     if err != nil {
         return err
@@ -158,60 +651,1052 @@ func drawAddEditor(g *gocui.Gui, _ *gocui.View) error {
 }
 
 func addCrontabJob(g *gocui.Gui, v *gocui.View) error {
-    input := strings.TrimSpace(v.Buffer())
+    input := popupInput(v)
     if input == "" {
         g.DeleteView(addCommandPanel.ViewName)
-        g.SetCurrentView(crontablistPanel.ViewName)
-        return nil
+        return returnToList(g)
     }
-    fields := strings.Fields(input)
-    if len(fields) < 6 {
-        return redrawPopupError(g, v, "Invalid format.\nUse: M H DOM MON DOW COMMAND")
+    schedule, user, command, err := parseJobInput(input, crontabSource.Format())
+    if err != nil {
+        addCommandPanel.HasError = true
+        return redrawPopupError(g, v, err.Error())
     }
-    schedule := fields[:5]
-    command := strings.Join(fields[5:], " ")
-    if err := utils.ValidateScheduleStrict(schedule); err != nil {
-        return redrawPopupError(g, v, "Schedule error:\n"+err.Error())
+    if warning := percentWarning(command); warning != "" && input != percentWarned {
+        percentWarned = input
+        addCommandPanel.HasError = true
+        return redrawPopupWarning(g, v, warning, input)
     }
 
-    if err := utils.ValidateCommand(command); err != nil {
-        return redrawPopupError(g, v, "Command error:\n"+err.Error())
+    err = AppendCrontabJob(crontabSource, schedule, user, command)
+    if conflict, ok := err.(*ConflictError); ok {
+        g.DeleteView(addCommandPanel.ViewName)
+        return showConflict(g, conflict)
     }
-
-    if err := AppendCrontabJob(CRON_FILE, schedule, command); err != nil {
+    if err != nil {
+        addCommandPanel.HasError = true
         return redrawPopupError(g, v, "Cannot write:\n"+err.Error())
     }
-    
 
     g.DeleteView(addCommandPanel.ViewName)
-    g.SetCurrentView(crontablistPanel.ViewName)
-    return nil
+    if err := returnToList(g); err != nil {
+        return err
+    }
+    return changeDone(g, "Added a job")
 }
 
-func redrawPopupError(g *gocui.Gui, v *gocui.View, msg string) error {
-    g.Update(func(g *gocui.Gui) error {
-        v.Clear()
-        // Red text (ANSI escape)
-        fmt.Fprintf(v, "\033[31m%s\033[0m\n\n", msg)
-        fmt.Fprintf(v, "Please fix and press ENTER:\n")
-        fmt.Fprintln(v, "")
-        x, y := v.Cursor()
-        v.SetCursor(x, y)
-        v.SetOrigin(0, y)
-        return nil
-    })
-    return nil
+// popupInput returns the last non-empty line of an editor popup, which is
+// where the user types after an error message was shown above it.
+func popupInput(v *gocui.View) string {
+    lines := v.BufferLines()
+    for i := len(lines) - 1; i >= 0; i-- {
+        if line := strings.TrimSpace(lines[i]); line != "" {
+            return line
+        }
+    }
+    return ""
 }
 
-func AppendCrontabJob(filePath string, schedule []string, command string) error {
-    f, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
-    if err != nil {
-        return err
+// parseJobInput splits and validates what was typed in the add and edit
+// popups. The error text is meant to be shown in the popup.
+func parseJobInput(input string, format parser.Format) ([]string, string, string, error) {
+    schedule, user, command := parser.SplitJobLine(input, format)
+    if command == "" {
+        if format == parser.SystemFormat {
+            return nil, "", "", fmt.Errorf("Invalid format.\nUse: M H DOM MON DOW USER COMMAND")
+        }
+        return nil, "", "", fmt.Errorf("Invalid format.\nUse: M H DOM MON DOW COMMAND")
     }
-    defer f.Close()
-    line := fmt.Sprintf("%s %s\n", strings.Join(schedule, " "), command)
-    _, err = f.WriteString(line)
-    return err
+    if err := utils.ValidateScheduleStrict(schedule); err != nil {
+        return nil, "", "", fmt.Errorf("Schedule error:\n%v", err)
+    }
+
+    if format == parser.SystemFormat {
+        if err := utils.ValidateUser(user); err != nil {
+            return nil, "", "", fmt.Errorf("User error:\n%v", err)
+        }
+    }
+
+    if err := utils.ValidateCommand(command); err != nil {
+        return nil, "", "", fmt.Errorf("Command error:\n%v", err)
+    }
+    return schedule, user, command, nil
+}
+
+// percentWarning explains a % in command that cron would end the command
+// at although it looks meant for it, empty if there is none.
+func percentWarning(command string) string {
+    i := parser.UnintendedPercent(command)
+    if i < 0 {
+        return ""
+    }
+    start := strings.LastIndexAny(command[:i], " \t") + 1
+    end := len(command)
+    if n := strings.IndexAny(command[i:], " \t"); n >= 0 {
+        end = i + n
+    }
+    return fmt.Sprintf("the %% in %q ends the command, cron passes the rest as standard input", command[start:end])
+}
+
+func drawEditEditor(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    job := selectedJob(g)
+    if job == nil {
+        return nil
+    }
+    percentWarned = ""
+    return editCommandPanel.DrawView(g, job)
+}
+
+func editCrontabJob(g *gocui.Gui, v *gocui.View) error {
+    input := popupInput(v)
+    if input == "" {
+        return closeEditor(g)
+    }
+    schedule, user, command, err := parseJobInput(input, crontabSource.Format())
+    if err != nil {
+        editCommandPanel.HasError = true
+        return redrawPopupError(g, v, err.Error())
+    }
+    if warning := percentWarning(command); warning != "" && input != percentWarned {
+        percentWarned = input
+        editCommandPanel.HasError = true
+        return redrawPopupWarning(g, v, warning, input)
+    }
+
+    err = ReplaceCrontabJob(crontabSource, editCommandPanel.Job, schedule, user, command)
+    if conflict, ok := err.(*ConflictError); ok {
+        g.DeleteView(editCommandPanel.ViewName)
+        return showConflict(g, conflict)
+    }
+    if err != nil {
+        editCommandPanel.HasError = true
+        return redrawPopupError(g, v, "Cannot write:\n"+err.Error())
+    }
+    if err := closeEditor(g); err != nil {
+        return err
+    }
+    return changeDone(g, fmt.Sprintf("Edited line %d", editCommandPanel.Job.LineNumber))
+}
+
+// cancelEdit restores the original job after an error, or closes the
+// editor.
+func cancelEdit(g *gocui.Gui, v *gocui.View) error {
+    if editCommandPanel.HasError {
+        return editCommandPanel.DrawView(g, editCommandPanel.Job)
+    }
+    return closeEditor(g)
+}
+
+func closeEditor(g *gocui.Gui) error {
+    g.DeleteView(editCommandPanel.ViewName)
+    return returnToList(g)
+}
+
+func redrawPopupError(g *gocui.Gui, v *gocui.View, msg string) error {
+    g.Update(func(g *gocui.Gui) error {
+        v.Clear()
+        // Red text (ANSI escape)
+        fmt.Fprintf(v, "\033[31m%s\033[0m\n\n", msg)
+        fmt.Fprintf(v, "Please fix and press ENTER:\n")
+        fmt.Fprintln(v, "")
+        x, y := v.Cursor()
+        v.SetCursor(x, y)
+        v.SetOrigin(0, y)
+        return nil
+    })
+    return nil
+}
+
+// redrawPopupWarning shows msg above the input, which is kept as typed so
+// that Enter saves it anyway.
+func redrawPopupWarning(g *gocui.Gui, v *gocui.View, msg string, input string) error {
+    g.Update(func(g *gocui.Gui) error {
+        v.Clear()
+        fmt.Fprintf(v, "\033[33m%s\033[0m\n", msg)
+        fmt.Fprintf(v, "Tab escapes every %% as \\%%, ENTER saves the line as it is:\n")
+        setPopupInput(v, 2, input)
+        return nil
+    })
+    return nil
+}
+
+// setPopupInput writes input on line y of an editor popup with the cursor
+// after it.
+func setPopupInput(v *gocui.View, y int, input string) {
+    fmt.Fprint(v, input)
+    width, _ := v.Size()
+    if len(input) < width {
+        v.SetOrigin(0, 0)
+        v.SetCursor(len(input), y)
+    } else {
+        v.SetOrigin(len(input)-width+1, 0)
+        v.SetCursor(width-1, y)
+    }
+}
+
+// escapeInputPercent rewrites the line typed in the add or edit popup with
+// the % signs of its command escaped, so that all of it is the command.
+func escapeInputPercent(g *gocui.Gui, v *gocui.View) error {
+    schedule, user, command := parser.SplitJobLine(popupInput(v), crontabSource.Format())
+    if command == "" {
+        return nil
+    }
+    line := parser.FormatJobLine(schedule, user, parser.EscapePercent(command))
+    percentWarned = ""
+    g.Update(func(g *gocui.Gui) error {
+        v.Clear()
+        setPopupInput(v, 0, line)
+        return nil
+    })
+    return nil
+}
+
+// askConfirmation opens the confirm popup, action runs on y/Enter.
+func askConfirmation(g *gocui.Gui, title string, message string, action func(g *gocui.Gui) error) error {
+    confirmAction = action
+    return confirmPanel.DrawView(g, title, message)
+}
+
+// confirm runs the action before going back to the list, so that a held
+// back reload cannot hide a conflict from it.
+func confirm(g *gocui.Gui, v *gocui.View) error {
+    action := confirmAction
+    confirmAction = nil
+    g.DeleteView(confirmPanel.ViewName)
+    g.SetCurrentView(crontablistPanel.ViewName)
+    if action != nil {
+        if err := action(g); err != nil {
+            return err
+        }
+    }
+    if g.CurrentView().Name() != crontablistPanel.ViewName {
+        // The action opened another popup.
+        return nil
+    }
+    return returnToList(g)
+}
+
+func cancelConfirm(g *gocui.Gui, _ *gocui.View) error {
+    confirmAction = nil
+    g.DeleteView(confirmPanel.ViewName)
+    return returnToList(g)
+}
+
+func confirmDelete(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    job := selectedJob(g)
+    if job == nil {
+        return nil
+    }
+    message := fmt.Sprintf("%s\n\n%s", job.Raw, job.Description)
+    return askConfirmation(g, fmt.Sprintf("Delete line %d?", job.LineNumber), message, func(g *gocui.Gui) error {
+        if err := DeleteCrontabJob(crontabSource, job); err != nil {
+            return writeFailed(g, err)
+        }
+        return changeDone(g, fmt.Sprintf("Deleted line %d", job.LineNumber))
+    })
+}
+
+func toggleJob(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    job := selectedJob(g)
+    if job == nil {
+        return nil
+    }
+    if job.Err != nil {
+        return statusPanel.SetError(g, fmt.Errorf("line %d is invalid, fix it with e first", job.LineNumber))
+    }
+    if err := ToggleCrontabJob(crontabSource, job); err != nil {
+        return writeFailed(g, err)
+    }
+    if job.Disabled {
+        return changeDone(g, fmt.Sprintf("Enabled line %d", job.LineNumber))
+    }
+    return changeDone(g, fmt.Sprintf("Disabled line %d", job.LineNumber))
+}
+
+// nextInvalid selects the next line cron rejects after the cursor,
+// wrapping around.
+func nextInvalid(g *gocui.Gui, v *gocui.View) error {
+    list := crontablistPanel.Visible()
+    if list == nil || list.Invalid() == 0 {
+        return statusPanel.SetMessage(g, "No invalid lines")
+    }
+    _, oy := v.Origin()
+    _, cy := v.Cursor()
+    for i := 1; i <= len(list.CronJobs); i++ {
+        idx := (oy + cy + i) % len(list.CronJobs)
+        if list.CronJobs[idx].Err != nil {
+            if err := crontablistPanel.Select(g, idx); err != nil {
+                return err
+            }
+            return drawSelectedDescription(g)
+        }
+    }
+    return nil
+}
+
+func undo(g *gocui.Gui, _ *gocui.View) error {
+    action, err := undoStack.Undo()
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    if staging {
+        if err := reloadCrontab(g); err != nil {
+            return err
+        }
+        showStageMode(g)
+    }
+    return statusPanel.SetMessage(g, "Undid "+action)
+}
+
+func drawBackups(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    backed := source.BackupsOf(crontabSource)
+    if backed == nil {
+        return statusPanel.SetError(g, fmt.Errorf("no backups are kept for %s", crontabSource.Name()))
+    }
+    backups, err := backed.Backups()
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    return backupsPanel.DrawView(g, crontabSource.Name(), backups)
+}
+
+func confirmRestore(g *gocui.Gui, _ *gocui.View) error {
+    backup, ok := backupsPanel.Selected(g)
+    if !ok {
+        return nil
+    }
+    closeBackups(g, nil)
+    data, err := backup.Read()
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    when := backup.Time.Local().Format("2006-01-02 15:04:05")
+    message := fmt.Sprintf("%s\n\nThe current crontab is backed up first, u undoes the restore.", strings.TrimRight(string(data), "\n"))
+    return askConfirmation(g, "Restore backup from "+when+"?", message, func(g *gocui.Gui) error {
+        if err := RestoreBackup(crontabSource, backup); err != nil {
+            return statusPanel.SetError(g, err)
+        }
+        return statusPanel.SetMessage(g, "Restored backup from "+when+" (u to undo)")
+    })
+}
+
+func closeBackups(g *gocui.Gui, _ *gocui.View) error {
+    g.DeleteView(backupsPanel.ViewName)
+    return returnToList(g)
+}
+
+// writeFailed reports a failed change, opening the merge popup when the
+// crontab was changed by someone else.
+func writeFailed(g *gocui.Gui, err error) error {
+    if conflict, ok := err.(*ConflictError); ok {
+        return showConflict(g, conflict)
+    }
+    return statusPanel.SetError(g, err)
+}
+
+func showConflict(g *gocui.Gui, conflict *ConflictError) error {
+    activeConflict = conflict
+    hunks := diff.Merge(diff.Lines(conflict.Base), diff.Lines(conflict.Mine), diff.Lines(conflict.Theirs))
+    title := fmt.Sprintf("%s changed on disk, merge \"%s\"", conflict.Src.Name(), conflict.Action)
+    return conflictPanel.DrawView(g, title, hunks)
+}
+
+func conflictMove(d int) func(g *gocui.Gui, v *gocui.View) error {
+    return func(g *gocui.Gui, v *gocui.View) error {
+        return conflictPanel.Move(g, d)
+    }
+}
+
+func conflictChoose(choice diff.Choice) func(g *gocui.Gui, v *gocui.View) error {
+    return func(g *gocui.Gui, v *gocui.View) error {
+        return conflictPanel.Choose(g, choice)
+    }
+}
+
+// saveMerge writes the merge once every conflict has been decided.
+func saveMerge(g *gocui.Gui, _ *gocui.View) error {
+    merged, err := diff.Resolve(conflictPanel.Hunks)
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    return resolveConflict(g, merged, "Saved the merge")
+}
+
+// keepMine writes the change as if nothing else had touched the crontab.
+func keepMine(g *gocui.Gui, _ *gocui.View) error {
+    return resolveConflict(g, activeConflict.Mine, "Overwrote the external change")
+}
+
+// takeTheirs drops the change, or all staged changes, and shows what is
+// on disk.
+func takeTheirs(g *gocui.Gui, _ *gocui.View) error {
+    if stage != nil && stage.Src == activeConflict.Src {
+        clearStage()
+        showStageMode(g)
+    }
+    activeConflict = nil
+    g.DeleteView(conflictPanel.ViewName)
+    reloadPending = true
+    if err := returnToList(g); err != nil {
+        return err
+    }
+    return statusPanel.SetMessage(g, "Discarded the change, showing the crontab from disk")
+}
+
+func resolveConflict(g *gocui.Gui, data []byte, message string) error {
+    err := ResolveConflict(activeConflict, data)
+    if conflict, ok := err.(*ConflictError); ok {
+        // Changed yet again while merging.
+        return showConflict(g, conflict)
+    }
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    if stage != nil && stage.Src == activeConflict.Src {
+        clearStage()
+        showStageMode(g)
+    }
+    activeConflict = nil
+    g.DeleteView(conflictPanel.ViewName)
+    reloadPending = true
+    if err := returnToList(g); err != nil {
+        return err
+    }
+    return statusPanel.SetMessage(g, message+" (u to undo)")
+}
+
+func drawExportEditor(g *gocui.Gui, _ *gocui.View) error {
+    if crontablistPanel.Visible() == nil {
+        return nil
+    }
+    return exportPanel.DrawView(g, "crontab.json")
+}
+
+// exportJobs writes the jobs currently listed, as filtered in the
+// aggregate view, to the path typed in the popup.
+func exportJobs(g *gocui.Gui, v *gocui.View) error {
+    path := popupInput(v)
+    if path == "" {
+        return closeExport(g, v)
+    }
+    list := crontablistPanel.Visible()
+    if err := ExportResult(list, path); err != nil {
+        exportPanel.HasError = true
+        return redrawPopupError(g, v, "Cannot export:\n"+err.Error())
+    }
+    g.DeleteView(exportPanel.ViewName)
+    if err := returnToList(g); err != nil {
+        return err
+    }
+    return statusPanel.SetMessage(g, fmt.Sprintf("Exported %d job(s) to %s", len(list.Export(time.Now(), 0)), path))
+}
+
+// closeExport restores the path after an error, or closes the popup.
+func closeExport(g *gocui.Gui, _ *gocui.View) error {
+    if exportPanel.HasError {
+        return exportPanel.DrawView(g, "crontab.json")
+    }
+    g.DeleteView(exportPanel.ViewName)
+    return returnToList(g)
+}
+
+// pendingImport is the import shown in the diff view, whose mode r
+// switches until the staged changes are saved or discarded.
+var pendingImport *ImportPlan
+
+// importStaging is set when an import turned on staged mode, which ends
+// again with the import.
+var importStaging bool
+
+func drawImportEditor(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    return importPanel.DrawView(g, "crontab.json")
+}
+
+// importJobs stages the jobs of the file typed in the popup and shows the
+// result as a diff. Imports are always staged so they can be reviewed.
+func importJobs(g *gocui.Gui, v *gocui.View) error {
+    path := popupInput(v)
+    if path == "" {
+        return closeImport(g, v)
+    }
+    plan, err := ReadImport(path, "", crontabSource.Format())
+    if err == nil {
+        err = stageImport(plan)
+    }
+    if err != nil {
+        importPanel.HasError = true
+        return redrawPopupError(g, v, "Cannot import:\n"+strings.ReplaceAll(err.Error(), "; ", "\n"))
+    }
+    g.DeleteView(importPanel.ViewName)
+    return showImport(g)
+}
+
+func stageImport(plan *ImportPlan) error {
+    if err := stageChange(crontabSource, plan.action(), plan.Apply); err != nil {
+        return err
+    }
+    if !staging {
+        staging, importStaging = true, true
+    }
+    pendingImport = plan
+    return nil
+}
+
+func showImport(g *gocui.Gui) error {
+    if err := reloadCrontab(g); err != nil {
+        return err
+    }
+    showStageMode(g)
+    text, err := stage.Diff()
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    other := "replace"
+    if pendingImport.Replace {
+        other = "merge"
+    }
+    title := fmt.Sprintf("Import %s (%s): %s, r: %s", pendingImport.Path, pendingImport.mode(), pendingImport.summary(), other)
+    return diffPanel.DrawView(g, title, text)
+}
+
+// toggleImportMode redoes the import just staged in the other mode.
+func toggleImportMode(g *gocui.Gui, _ *gocui.View) error {
+    if pendingImport == nil || undoStack.Len() == 0 {
+        return nil
+    }
+    last := undoStack.entries[undoStack.Len()-1]
+    if !last.staged || last.action != pendingImport.action() {
+        return nil
+    }
+    plan := *pendingImport
+    plan.Replace = !plan.Replace
+    if plan.Replace && len(plan.Jobs) == 0 {
+        return statusPanel.SetError(g, errEmptyReplace)
+    }
+    if _, err := undoStack.Undo(); err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    if err := stageImport(&plan); err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    return showImport(g)
+}
+
+// closeImport restores the path after an error, or closes the popup.
+func closeImport(g *gocui.Gui, _ *gocui.View) error {
+    if importPanel.HasError {
+        return importPanel.DrawView(g, "crontab.json")
+    }
+    g.DeleteView(importPanel.ViewName)
+    return returnToList(g)
+}
+
+// drawDiagnostics lints what the list shows.
+func drawDiagnostics(g *gocui.Gui, _ *gocui.View) error {
+    sources := []source.Source{crontabSource}
+    if aggregate {
+        sources = crontabSources
+    }
+    diagnostics, errs := lintSources(sources)
+    if err := diagnosticsPanel.DrawView(g, diagnostics, aggregate); err != nil {
+        return err
+    }
+    if len(errs) > 0 {
+        return statusPanel.SetError(g, fmt.Errorf("%d source(s) unreadable, first: %v", len(errs), errs[0]))
+    }
+    return showSelectedFix(g)
+}
+
+func showSelectedFix(g *gocui.Gui) error {
+    d, ok := diagnosticsPanel.Selected(g)
+    if !ok || d.Fix == nil {
+        return statusPanel.SetMessage(g, "")
+    }
+    return statusPanel.SetMessage(g, "Fix: "+d.Fix.Description)
+}
+
+// gotoDiagnostic selects the job the diagnostic is about in the list.
+func gotoDiagnostic(g *gocui.Gui, _ *gocui.View) error {
+    d, ok := diagnosticsPanel.Selected(g)
+    if !ok {
+        return nil
+    }
+    if err := closeDiagnostics(g, nil); err != nil {
+        return err
+    }
+    for i, job := range crontablistPanel.Visible().CronJobs {
+        if job.Source == d.Source && job.LineNumber == d.Line {
+            if err := crontablistPanel.Select(g, i); err != nil {
+                return err
+            }
+            drawSelectedDescription(g)
+            return statusPanel.SetMessage(g, fmt.Sprintf("Line %d: %s", d.Line, d.Message))
+        }
+    }
+    return statusPanel.SetMessage(g, fmt.Sprintf("Line %d is not listed: %s", d.Line, d.Message))
+}
+
+// applyFix replaces the line of the selected diagnostic with its fix,
+// provided linting the crontab again still suggests the same.
+func applyFix(g *gocui.Gui, _ *gocui.View) error {
+    d, ok := diagnosticsPanel.Selected(g)
+    if !ok {
+        return nil
+    }
+    if d.Fix == nil || d.Fix.Line == "" {
+        return statusPanel.SetError(g, fmt.Errorf("no automatic fix for %s", d.Rule))
+    }
+    if !requireWritable(g) {
+        return nil
+    }
+    action := fmt.Sprintf("fix line %d", d.Line)
+    err := mutateCrontab(crontabSource, action, func(doc *parser.Document) error {
+        for _, again := range lint.Check(doc) {
+            if again.Line == d.Line && again.Rule == d.Rule && again.Fix != nil && again.Fix.Line == d.Fix.Line {
+                _, err := doc.ReplaceLine(d.Line, d.Fix.Line)
+                return err
+            }
+        }
+        return fmt.Errorf("line %d changed, lint again", d.Line)
+    })
+    if err != nil {
+        g.DeleteView(diagnosticsPanel.ViewName)
+        g.SetCurrentView(crontablistPanel.ViewName)
+        return writeFailed(g, err)
+    }
+    if err := drawDiagnostics(g, nil); err != nil {
+        return err
+    }
+    return changeDone(g, fmt.Sprintf("Fixed line %d", d.Line))
+}
+
+func closeDiagnostics(g *gocui.Gui, _ *gocui.View) error {
+    g.DeleteView(diagnosticsPanel.ViewName)
+    return returnToList(g)
+}
+
+// editingEnv is the assignment open in the variable editor, nil when
+// adding one.
+var editingEnv *parser.Line
+
+// currentDocument is the crontab as listed, with staged changes applied.
+func currentDocument(src source.Source) (*parser.Document, error) {
+    if stage != nil && stage.Src == src {
+        return parser.ParseDocumentFormat(stage.Data, src.Format()), nil
+    }
+    data, err := src.Read()
+    if err != nil {
+        return nil, err
+    }
+    return parser.ParseDocumentFormat(data, src.Format()), nil
+}
+
+// envSource is the crontab whose variables the panel shows, in the
+// aggregate view the one the selected job comes from.
+func envSource(g *gocui.Gui) source.Source {
+    if !aggregate {
+        return crontabSource
+    }
+    if job := selectedJob(g); job != nil {
+        for _, src := range crontabSources {
+            if source.Origin(src) == job.Source {
+                return src
+            }
+        }
+    }
+    return crontabSource
+}
+
+// drawEnv opens the variable panel, also in read-only views. Changing a
+// variable is checked in the add, edit and delete handlers.
+func drawEnv(g *gocui.Gui, _ *gocui.View) error {
+    src := envSource(g)
+    doc, err := currentDocument(src)
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    return envPanel.DrawView(g, src.Name(), doc.Variables())
+}
+
+func drawEnvAdd(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    editingEnv = nil
+    envEditPanel.SetTitle("Set a variable for every job (NAME=value)")
+    return envEditPanel.DrawView(g, "")
+}
+
+func drawEnvEdit(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    line, ok := envPanel.Selected(g)
+    if !ok {
+        return nil
+    }
+    editingEnv = line
+    envEditPanel.SetTitle(fmt.Sprintf("Edit line %d (NAME=value)", line.Number))
+    return envEditPanel.DrawView(g, strings.TrimSpace(line.Text))
+}
+
+// saveEnv applies what was typed in the variable editor. The value is
+// written back quoted where cron would otherwise trim or unquote it.
+func saveEnv(g *gocui.Gui, v *gocui.View) error {
+    input := popupInput(v)
+    if input == "" {
+        return cancelEnvEdit(g, v)
+    }
+    env, ok := parser.ParseEnvLine(input)
+    if !ok {
+        envEditPanel.HasError = true
+        return redrawPopupError(g, v, "Invalid format.\nUse: NAME=value")
+    }
+    if _, err := parser.FormatEnvLine(env.Name, env.Value); err != nil {
+        envEditPanel.HasError = true
+        return redrawPopupError(g, v, err.Error())
+    }
+    var err error
+    message := "Set " + env.Name
+    if editingEnv == nil {
+        err = AddCrontabEnv(crontabSource, env.Name, env.Value)
+    } else {
+        err = ReplaceCrontabEnv(crontabSource, editingEnv, env.Name, env.Value)
+        message = fmt.Sprintf("Edited line %d", editingEnv.Number)
+    }
+    if conflict, ok := err.(*ConflictError); ok {
+        g.DeleteView(envEditPanel.ViewName)
+        g.DeleteView(envPanel.ViewName)
+        return showConflict(g, conflict)
+    }
+    if err != nil {
+        envEditPanel.HasError = true
+        return redrawPopupError(g, v, "Cannot write:\n"+err.Error())
+    }
+    g.DeleteView(envEditPanel.ViewName)
+    if err := drawEnv(g, nil); err != nil {
+        return err
+    }
+    return changeDone(g, message)
+}
+
+// cancelEnvEdit restores the input after an error, or goes back to the
+// list of variables.
+func cancelEnvEdit(g *gocui.Gui, v *gocui.View) error {
+    if envEditPanel.HasError {
+        if editingEnv == nil {
+            return drawEnvAdd(g, v)
+        }
+        return envEditPanel.DrawView(g, strings.TrimSpace(editingEnv.Text))
+    }
+    g.DeleteView(envEditPanel.ViewName)
+    _, err := g.SetCurrentView(envPanel.ViewName)
+    return err
+}
+
+func confirmEnvDelete(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    line, ok := envPanel.Selected(g)
+    if !ok {
+        return nil
+    }
+    g.DeleteView(envPanel.ViewName)
+    return askConfirmation(g, fmt.Sprintf("Delete line %d?", line.Number), line.Text, func(g *gocui.Gui) error {
+        if err := DeleteCrontabEnv(crontabSource, line); err != nil {
+            return writeFailed(g, err)
+        }
+        if err := drawEnv(g, nil); err != nil {
+            return err
+        }
+        return changeDone(g, fmt.Sprintf("Deleted line %d", line.Number))
+    })
+}
+
+func closeEnv(g *gocui.Gui, _ *gocui.View) error {
+    g.DeleteView(envPanel.ViewName)
+    return returnToList(g)
+}
+
+// confirmRun asks before running the selected job's command.
+func confirmRun(g *gocui.Gui, _ *gocui.View) error {
+    job := selectedJob(g)
+    if job == nil {
+        return nil
+    }
+    if job.Err != nil {
+        return statusPanel.SetError(g, fmt.Errorf("line %d is invalid, cron would not run it", job.LineNumber))
+    }
+    if activeRun != nil {
+        return statusPanel.SetError(g, fmt.Errorf("a job is still running"))
+    }
+    run, err := runner.New(job, jobOwner())
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    message := job.Command
+    if !run.AsAccount() {
+        message += fmt.Sprintf("\n\nCron runs it as %s, it runs as %s here, with the environment %s would get.", run.Account.Name, currentUser(), run.Account.Name)
+    }
+    return askConfirmation(g, fmt.Sprintf("Run line %d now?", job.LineNumber), message, func(g *gocui.Gui) error {
+        return startRun(g, job, run)
+    })
+}
+
+// jobOwner is the user the jobs of the current crontab run as when they
+// name none, empty for the user running crontab-tui.
+func jobOwner() string {
+    if aggregate {
+        return ""
+    }
+    return source.Owner(crontabSource)
+}
+
+func currentUser() string {
+    if name := os.Getenv("USER"); name != "" {
+        return name
+    }
+    return os.Getenv("LOGNAME")
+}
+
+// startRun runs the job in the background, its output is shown as it
+// comes.
+func startRun(g *gocui.Gui, job *parser.CronJob, run *runner.Run) error {
+    header := fmt.Sprintf("$ %s -c %s", run.Shell, run.Command)
+    if run.HasInput {
+        header += fmt.Sprintf("\nstandard input: %q", run.Input)
+    }
+    if err := outputPanel.DrawView(g, fmt.Sprintf("Line %d", job.LineNumber), header); err != nil {
+        return err
+    }
+    err := run.Start(func(data []byte) {
+        // One queued Flush takes everything written until it runs.
+        if outputPanel.Append(data) {
+            g.Update(outputPanel.Flush)
+        }
+    }, func(result runner.Result) {
+        g.Update(func(g *gocui.Gui) error {
+            if activeRun == run {
+                activeRun = nil
+            }
+            return outputPanel.Finish(g, result.String(), result.ExitCode == 0 && result.Err == nil && !result.Canceled)
+        })
+    })
+    if err != nil {
+        return outputPanel.Finish(g, "cannot start: "+err.Error(), false)
+    }
+    activeRun = run
+    return nil
+}
+
+func outputScroll(d int) func(g *gocui.Gui, v *gocui.View) error {
+    return func(g *gocui.Gui, v *gocui.View) error {
+        return outputPanel.Scroll(g, d)
+    }
+}
+
+func cancelRun(g *gocui.Gui, _ *gocui.View) error {
+    if activeRun != nil {
+        activeRun.Cancel()
+    }
+    return nil
+}
+
+// closeOutput closes the panel, canceling the job if it still runs.
+func closeOutput(g *gocui.Gui, v *gocui.View) error {
+    cancelRun(g, v)
+    g.DeleteView(outputPanel.ViewName)
+    return returnToList(g)
+}
+
+// drawEnvCompare shows the environment cron gives the selected job next
+// to the one crontab-tui was started in.
+func drawEnvCompare(g *gocui.Gui, _ *gocui.View) error {
+    job := selectedJob(g)
+    if job == nil {
+        return nil
+    }
+    account, err := runner.LookupAccount(job, jobOwner())
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    changes := runner.CompareEnvironment(runner.Environment(job, account), os.Environ())
+    var text strings.Builder
+    runner.WriteComparison(&text, changes, "YOUR SHELL", envComparePanel.ValueWidth(g), true)
+    title := fmt.Sprintf("Environment of line %d under cron (as %s) and in your shell", job.LineNumber, account.Name)
+    return envComparePanel.DrawView(g, title, text.String())
+}
+
+func envCompareScroll(d int) func(g *gocui.Gui, v *gocui.View) error {
+    return func(g *gocui.Gui, v *gocui.View) error {
+        return envComparePanel.Scroll(g, d)
+    }
+}
+
+func closeEnvCompare(g *gocui.Gui, _ *gocui.View) error {
+    g.DeleteView(envComparePanel.ViewName)
+    return returnToList(g)
+}
+
+// setDisplayZone shows next runs in loc, nil being the system's zone.
+func setDisplayZone(loc *time.Location) {
+    if loc == time.Local {
+        loc = nil
+    }
+    crontablistPanel.Location = loc
+    descriptionPanel.Location = loc
+}
+
+func displayZoneName() string {
+    if crontablistPanel.Location == nil {
+        return "Local"
+    }
+    return crontablistPanel.Location.String()
+}
+
+func drawZoneEditor(g *gocui.Gui, _ *gocui.View) error {
+    return zonePanel.DrawView(g, displayZoneName())
+}
+
+func saveZone(g *gocui.Gui, v *gocui.View) error {
+    name := popupInput(v)
+    if name == "" {
+        return closeZone(g, v)
+    }
+    loc, err := parser.LoadZone(name)
+    if err != nil {
+        zonePanel.HasError = true
+        return redrawPopupError(g, v, fmt.Sprintf("Unknown time zone %q.\nUse a name such as Europe/Berlin, UTC or Local", name))
+    }
+    g.DeleteView(zonePanel.ViewName)
+    setDisplayZone(loc)
+    if err := returnToList(g); err != nil {
+        return err
+    }
+    if err := refreshPanels(g); err != nil {
+        return err
+    }
+    return statusPanel.SetMessage(g, "Showing times in "+displayZoneName())
+}
+
+// closeZone restores the name after an error, or closes the popup.
+func closeZone(g *gocui.Gui, _ *gocui.View) error {
+    if zonePanel.HasError {
+        return zonePanel.DrawView(g, displayZoneName())
+    }
+    g.DeleteView(zonePanel.ViewName)
+    return returnToList(g)
+}
+
+// changeDone reports a successful change. Staged changes are not written,
+// so no file event reloads the list: it is reloaded here.
+func changeDone(g *gocui.Gui, message string) error {
+    if !staging {
+        return statusPanel.SetMessage(g, message+" (u to undo)")
+    }
+    if err := reloadCrontab(g); err != nil {
+        return err
+    }
+    showStageMode(g)
+    return statusPanel.SetMessage(g, message+" (staged, w: save)")
+}
+
+func showStageMode(g *gocui.Gui) error {
+    if !staging {
+        return statusPanel.SetMode(g, "")
+    }
+    return statusPanel.SetMode(g, fmt.Sprintf("STAGED %d  w: Save  x: Discard  D: Diff", stage.Len()))
+}
+
+// toggleStaging switches between writing every change immediately and
+// collecting them until saved.
+func toggleStaging(g *gocui.Gui, _ *gocui.View) error {
+    if !requireWritable(g) {
+        return nil
+    }
+    if staging && stage.Len() > 0 {
+        return statusPanel.SetError(g, fmt.Errorf("save (w) or discard (x) the staged changes first"))
+    }
+    staging = !staging
+    clearStage()
+    showStageMode(g)
+    if staging {
+        return statusPanel.SetMessage(g, "Staging changes until saved with w")
+    }
+    return statusPanel.SetMessage(g, "Writing changes immediately")
+}
+
+func drawStageDiff(g *gocui.Gui, _ *gocui.View) error {
+    if stage.Len() == 0 {
+        return statusPanel.SetMessage(g, "Nothing staged")
+    }
+    text, err := stage.Diff()
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    title := fmt.Sprintf("%d staged change(s)", stage.Len())
+    return diffPanel.DrawView(g, title, text)
+}
+
+func closeDiff(g *gocui.Gui, _ *gocui.View) error {
+    g.DeleteView(diffPanel.ViewName)
+    return returnToList(g)
+}
+
+func diffScroll(d int) func(g *gocui.Gui, v *gocui.View) error {
+    return func(g *gocui.Gui, v *gocui.View) error {
+        return diffPanel.Scroll(g, d)
+    }
+}
+
+func saveStaged(g *gocui.Gui, _ *gocui.View) error {
+    g.DeleteView(diffPanel.ViewName)
+    g.SetCurrentView(crontablistPanel.ViewName)
+    count := stage.Len()
+    err := SaveStage()
+    if conflict, ok := err.(*ConflictError); ok {
+        return showConflict(g, conflict)
+    }
+    if err != nil {
+        return statusPanel.SetError(g, err)
+    }
+    showStageMode(g)
+    if err := returnToList(g); err != nil {
+        return err
+    }
+    return statusPanel.SetMessage(g, fmt.Sprintf("Saved %d change(s) (u to undo)", count))
+}
+
+func confirmDiscard(g *gocui.Gui, _ *gocui.View) error {
+    g.DeleteView(diffPanel.ViewName)
+    g.SetCurrentView(crontablistPanel.ViewName)
+    if stage.Len() == 0 {
+        return statusPanel.SetMessage(g, "Nothing staged")
+    }
+    message := strings.Join(stage.Actions, "\n")
+    return askConfirmation(g, fmt.Sprintf("Discard %d staged change(s)?", stage.Len()), message, func(g *gocui.Gui) error {
+        clearStage()
+        showStageMode(g)
+        if err := reloadCrontab(g); err != nil {
+            return err
+        }
+        return statusPanel.SetMessage(g, "Discarded the staged changes")
+    })
 }
 
 func clearErrorOnType(g *gocui.Gui, v *gocui.View) error {
@@ -0,0 +1,55 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+    "crontab-tui/parser"
+    "crontab-tui/utils"
+)
+
+func TestParseJobInput(t *testing.T) {
+    defer func(file string) { utils.PasswdFile = file }(utils.PasswdFile)
+    utils.PasswdFile = filepath.Join(t.TempDir(), "passwd")
+    if err := os.WriteFile(utils.PasswdFile, []byte("alice:x:1000:1000::/home/alice:/bin/sh\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        input    string
+        format   parser.Format
+        schedule []string
+        user     string
+        command  string
+        wantErr  bool
+    }{
+        {"*/5 * * * * /bin/echo  hi", parser.UserFormat, []string{"*/5", "*", "*", "*", "*"}, "", "/bin/echo  hi", false},
+        {"@daily /bin/true", parser.UserFormat, []string{"@daily"}, "", "/bin/true", false},
+        {"0 9 * * MON-FRI /bin/sh -c date", parser.UserFormat, []string{"0", "9", "*", "*", "MON-FRI"}, "", "/bin/sh -c date", false},
+        {"0 9 * * * alice /bin/true", parser.SystemFormat, []string{"0", "9", "*", "*", "*"}, "alice", "/bin/true", false},
+        {"61 * * * * /bin/true", parser.UserFormat, nil, "", "", true},
+        {"0 9 * * *", parser.UserFormat, nil, "", "", true},
+        {"@weekly", parser.UserFormat, nil, "", "", true},
+        {"@fortnightly /bin/true", parser.UserFormat, nil, "", "", true},
+        {"@daily /bin/true", parser.SystemFormat, nil, "", "", true},
+        {"@daily no-such-user-here /bin/true", parser.SystemFormat, nil, "", "", true},
+    }
+
+    for _, tt := range tests {
+        schedule, user, command, err := parseJobInput(tt.input, tt.format)
+        if tt.wantErr {
+            if err == nil {
+                t.Errorf("parseJobInput(%q, %v) accepted", tt.input, tt.format)
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("parseJobInput(%q, %v): %v", tt.input, tt.format, err)
+            continue
+        }
+        if !reflect.DeepEqual(schedule, tt.schedule) || user != tt.user || command != tt.command {
+            t.Errorf("parseJobInput(%q, %v) = %q, %q, %q, want %q, %q, %q", tt.input, tt.format, schedule, user, command, tt.schedule, tt.user, tt.command)
+        }
+    }
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "fmt"
+    "crontab-tui/parser"
+    "crontab-tui/source"
+)
+
+// undoEntry remembers the file content around one mutation. Staged
+// entries hold the content of the staged document instead.
+type undoEntry struct {
+    src    source.Source
+    action string
+    before []byte
+    after  []byte
+    staged bool
+}
+
+// UndoStack holds every mutation made during this session.
+type UndoStack struct {
+    entries []undoEntry
+}
+
+var undoStack = &UndoStack{}
+
+func (s *UndoStack) push(src source.Source, action string, before, after []byte) {
+    s.entries = append(s.entries, undoEntry{src, action, before, after, false})
+}
+
+func (s *UndoStack) pushStaged(src source.Source, action string, before, after []byte) {
+    s.entries = append(s.entries, undoEntry{src, action, before, after, true})
+}
+
+// dropStaged forgets the staged changes once they were saved or discarded.
+func (s *UndoStack) dropStaged() {
+    kept := s.entries[:0]
+    for _, entry := range s.entries {
+        if !entry.staged {
+            kept = append(kept, entry)
+        }
+    }
+    s.entries = kept
+}
+
+func (s *UndoStack) Len() int {
+    return len(s.entries)
+}
+
+// Undo restores the content from before the most recent mutation. It
+// refuses if the file was changed by something else since then.
+func (s *UndoStack) Undo() (string, error) {
+    if len(s.entries) == 0 {
+        return "", fmt.Errorf("nothing to undo")
+    }
+    entry := s.entries[len(s.entries)-1]
+    if entry.staged {
+        if err := stage.undo(entry); err != nil {
+            return "", err
+        }
+        s.entries = s.entries[:len(s.entries)-1]
+        return entry.action, nil
+    }
+    src := entry.src
+    err := source.Update(src, func(current []byte) ([]byte, error) {
+        if !bytes.Equal(current, entry.after) {
+            return nil, fmt.Errorf("%s changed since \"%s\", cannot undo", src.Name(), entry.action)
+        }
+        return entry.before, nil
+    })
+    if err != nil {
+        return "", err
+    }
+    setBaseline(src, entry.before)
+    s.entries = s.entries[:len(s.entries)-1]
+    return entry.action, nil
+}
+
+// baseline is the content of a source as last shown to the user. Changes
+// are made against it, and a source whose content no longer hashes the
+// same was modified by someone else in the meantime.
+type baseline struct {
+    data []byte
+    sum  [sha256.Size]byte
+}
+
+var baselines = map[source.Source]baseline{}
+
+func setBaseline(src source.Source, data []byte) {
+    baselines[src] = baseline{data, sha256.Sum256(data)}
+}
+
+// matchesBaseline reports whether src still holds what was last loaded or
+// written, e.g. when a file event is just the echo of our own write.
+func matchesBaseline(src source.Source) bool {
+    base, ok := baselines[src]
+    if !ok {
+        return false
+    }
+    data, err := src.Read()
+    return err == nil && sha256.Sum256(data) == base.sum
+}
+
+// ConflictError is returned instead of writing when the source changed
+// since it was loaded. Mine is the change applied to what was loaded,
+// Theirs what is on disk now.
+type ConflictError struct {
+    Src    source.Source
+    Action string
+    Base   []byte
+    Mine   []byte
+    Theirs []byte
+}
+
+func (e *ConflictError) Error() string {
+    return fmt.Sprintf("%s changed on disk since it was loaded", e.Src.Name())
+}
+
+// mutateCrontab applies fn to the document as last loaded and writes the
+// result, recording the change on the undo stack. It returns a
+// *ConflictError when the source changed in between.
+func mutateCrontab(src source.Source, action string, fn func(doc *parser.Document) error) error {
+    if staging {
+        return stageChange(src, action, fn)
+    }
+    return updateCrontab(src, action, func(current []byte) ([]byte, error) {
+        base, tracked := baselines[src]
+        if !tracked {
+            base = baseline{current, sha256.Sum256(current)}
+        }
+        doc := parser.ParseDocumentFormat(base.data, src.Format())
+        if err := fn(doc); err != nil {
+            return nil, err
+        }
+        mine := doc.Bytes()
+        if sha256.Sum256(current) != base.sum {
+            return nil, &ConflictError{Src: src, Action: action, Base: base.data, Mine: mine, Theirs: current}
+        }
+        return mine, nil
+    })
+}
+
+// ResolveConflict writes the resolution of a conflict, provided the source
+// still holds what the user merged against.
+func ResolveConflict(conflict *ConflictError, data []byte) error {
+    return updateCrontab(conflict.Src, conflict.Action, func(current []byte) ([]byte, error) {
+        if !bytes.Equal(current, conflict.Theirs) {
+            again := *conflict
+            again.Theirs = current
+            return nil, &again
+        }
+        return data, nil
+    })
+}
+
+// updateCrontab installs what fn makes of the current content, holding the
+// source's lock from the read to the write, and records it for undo.
+func updateCrontab(src source.Source, action string, fn func(current []byte) ([]byte, error)) error {
+    var before, after []byte
+    err := source.Update(src, func(current []byte) ([]byte, error) {
+        data, err := fn(current)
+        before, after = current, data
+        return data, err
+    })
+    if err != nil {
+        return err
+    }
+    setBaseline(src, after)
+    undoStack.push(src, action, before, after)
+    return nil
+}
+
+// jobLine returns the line job was parsed from, making sure it still holds
+// the same text.
+func jobLine(doc *parser.Document, job *parser.CronJob) (*parser.Line, error) {
+    line, err := doc.LineAt(job.LineNumber)
+    if err != nil || line.Text != job.Raw {
+        return nil, fmt.Errorf("line %d changed on disk, reload and try again", job.LineNumber)
+    }
+    return line, nil
+}
+
+func AppendCrontabJob(src source.Source, schedule []string, user string, command string) error {
+    return mutateCrontab(src, "add job", func(doc *parser.Document) error {
+        doc.AppendJob(schedule, user, command)
+        return nil
+    })
+}
+
+// ReplaceCrontabJob rewrites the line job was parsed from, leaving every
+// other line untouched. It refuses if that line changed in the meantime.
+func ReplaceCrontabJob(src source.Source, job *parser.CronJob, schedule []string, user string, command string) error {
+    action := fmt.Sprintf("edit line %d", job.LineNumber)
+    return mutateCrontab(src, action, func(doc *parser.Document) error {
+        if _, err := jobLine(doc, job); err != nil {
+            return err
+        }
+        text := parser.FormatJobLine(schedule, user, command)
+        if job.Disabled {
+            text = parser.DisableLine(text)
+        }
+        _, err := doc.ReplaceLine(job.LineNumber, text)
+        return err
+    })
+}
+
+func DeleteCrontabJob(src source.Source, job *parser.CronJob) error {
+    action := fmt.Sprintf("delete line %d", job.LineNumber)
+    return mutateCrontab(src, action, func(doc *parser.Document) error {
+        if _, err := jobLine(doc, job); err != nil {
+            return err
+        }
+        return doc.DeleteLine(job.LineNumber)
+    })
+}
+
+func ToggleCrontabJob(src source.Source, job *parser.CronJob) error {
+    action := fmt.Sprintf("enable line %d", job.LineNumber)
+    if !job.Disabled {
+        action = fmt.Sprintf("disable line %d", job.LineNumber)
+    }
+    return mutateCrontab(src, action, func(doc *parser.Document) error {
+        if _, err := jobLine(doc, job); err != nil {
+            return err
+        }
+        _, err := doc.ToggleLine(job.LineNumber)
+        return err
+    })
+}
+
+// envLine returns the assignment on line, making sure it is still there.
+func envLine(doc *parser.Document, line *parser.Line) error {
+    current, err := doc.LineAt(line.Number)
+    if err != nil || current.Text != line.Text || current.Kind != parser.LineEnv {
+        return fmt.Errorf("line %d changed on disk, reload and try again", line.Number)
+    }
+    return nil
+}
+
+// AddCrontabEnv sets a variable for every job of the crontab.
+func AddCrontabEnv(src source.Source, name string, value string) error {
+    return mutateCrontab(src, "set "+name, func(doc *parser.Document) error {
+        _, err := doc.InsertEnv(name, value)
+        return err
+    })
+}
+
+func ReplaceCrontabEnv(src source.Source, line *parser.Line, name string, value string) error {
+    action := fmt.Sprintf("edit variable on line %d", line.Number)
+    return mutateCrontab(src, action, func(doc *parser.Document) error {
+        if err := envLine(doc, line); err != nil {
+            return err
+        }
+        text, err := parser.FormatEnvLine(name, value)
+        if err != nil {
+            return err
+        }
+        _, err = doc.ReplaceLine(line.Number, text)
+        return err
+    })
+}
+
+func DeleteCrontabEnv(src source.Source, line *parser.Line) error {
+    action := fmt.Sprintf("delete variable on line %d", line.Number)
+    return mutateCrontab(src, action, func(doc *parser.Document) error {
+        if err := envLine(doc, line); err != nil {
+            return err
+        }
+        return doc.DeleteLine(line.Number)
+    })
+}
+
+// RestoreBackup replaces the crontab with a backup. What it replaces is
+// backed up in turn and the restore can be undone like any other change.
+func RestoreBackup(src source.Source, backup source.Backup) error {
+    data, err := backup.Read()
+    if err != nil {
+        return err
+    }
+    action := "restore backup from " + backup.Time.Local().Format("2006-01-02 15:04:05")
+    return updateCrontab(src, action, func([]byte) ([]byte, error) {
+        return data, nil
+    })
+}
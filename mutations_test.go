@@ -0,0 +1,273 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "crontab-tui/diff"
+    "crontab-tui/parser"
+    "crontab-tui/source"
+)
+
+func TestReplaceCrontabJob(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "crontab")
+    content := "# backups  \r\nMAILTO=ops\r\n\r\n0 3 * * * /bin/backup --all\r\n#\tnote\r\n*/5 * * * * /bin/poll"
+    if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+    result, err := parser.ParseCrontab(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    job := &result.CronJobs[0]
+    src := source.NewFileSource(path)
+
+    if err := ReplaceCrontabJob(src, job, []string{"30", "4", "*", "*", "SUN"}, "", "/bin/backup  --full"); err != nil {
+        t.Fatal(err)
+    }
+    want := "# backups  \r\nMAILTO=ops\r\n\r\n30 4 * * SUN /bin/backup  --full\r\n#\tnote\r\n*/5 * * * * /bin/poll"
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if string(data) != want {
+        t.Errorf("crontab is %q, want %q", data, want)
+    }
+
+    // job still holds the old text, which is no longer on its line.
+    if err := ReplaceCrontabJob(src, job, []string{"@daily"}, "", "/bin/backup"); err == nil {
+        t.Error("replaced a line that changed on disk")
+    }
+    if data, _ := os.ReadFile(path); string(data) != want {
+        t.Errorf("crontab is %q after a refused edit, want %q", data, want)
+    }
+}
+
+func TestUndo(t *testing.T) {
+    appendJob := func(command string) func(src source.Source) error {
+        return func(src source.Source) error {
+            return AppendCrontabJob(src, []string{"@daily"}, "", command)
+        }
+    }
+    deleteFirst := func(src source.Source) error {
+        data, err := src.Read()
+        if err != nil {
+            return err
+        }
+        return DeleteCrontabJob(src, &parser.ParseDocument(data).Result().CronJobs[0])
+    }
+    setEnv := func(src source.Source) error {
+        return AddCrontabEnv(src, "MAILTO", "ops")
+    }
+
+    tests := []struct {
+        name     string
+        edits    []func(src source.Source) error
+        external string
+        undos    int
+        want     []string
+        err      string
+    }{
+        {"most recent first", []func(src source.Source) error{appendJob("/bin/a"), deleteFirst, appendJob("/bin/b")}, "", 3,
+            []string{"add job", "delete line 2", "add job"}, ""},
+        {"variables", []func(src source.Source) error{setEnv, appendJob("/bin/a"), setEnv}, "", 3,
+            []string{"set MAILTO", "add job", "set MAILTO"}, ""},
+        {"partial", []func(src source.Source) error{appendJob("/bin/a"), deleteFirst}, "", 1,
+            []string{"delete line 2"}, ""},
+        {"empty stack", nil, "", 1, nil, "nothing to undo"},
+        {"past the first change", []func(src source.Source) error{appendJob("/bin/a")}, "", 2,
+            []string{"add job"}, "nothing to undo"},
+        {"external change", []func(src source.Source) error{appendJob("/bin/a")}, "# edited elsewhere\n", 1,
+            nil, "changed since \"add job\", cannot undo"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            undoStack = &UndoStack{}
+            baselines = map[source.Source]baseline{}
+            path := filepath.Join(t.TempDir(), "crontab")
+            if err := os.WriteFile(path, []byte("# jobs\n@hourly /bin/true\n"), 0644); err != nil {
+                t.Fatal(err)
+            }
+            src := source.NewFileSource(path)
+
+            // versions[i] is the content before edit i, the last one what
+            // the edits left.
+            var versions []string
+            read := func() string {
+                data, err := os.ReadFile(path)
+                if err != nil {
+                    t.Fatal(err)
+                }
+                return string(data)
+            }
+            for _, edit := range tt.edits {
+                versions = append(versions, read())
+                if err := edit(src); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            versions = append(versions, read())
+            if tt.external != "" {
+                if err := os.WriteFile(path, []byte(tt.external), 0644); err != nil {
+                    t.Fatal(err)
+                }
+                versions[len(versions)-1] = tt.external
+            }
+
+            var got []string
+            var err error
+            for i := 0; i < tt.undos; i++ {
+                var action string
+                if action, err = undoStack.Undo(); err != nil {
+                    break
+                }
+                got = append(got, action)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("undone %q, want %q", got, tt.want)
+            }
+            switch {
+            case tt.err == "" && err != nil:
+                t.Errorf("unexpected error: %v", err)
+            case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+                t.Errorf("error %v, want %q", err, tt.err)
+            }
+            if want := versions[len(versions)-1-len(got)]; read() != want {
+                t.Errorf("content %q, want %q", read(), want)
+            }
+            if undoStack.Len() != len(tt.edits)-len(got) {
+                t.Errorf("%d entries left, want %d", undoStack.Len(), len(tt.edits)-len(got))
+            }
+        })
+    }
+}
+
+func TestMutateCrontabConflict(t *testing.T) {
+    const loaded = "# jobs\n@hourly /bin/true\n@daily /bin/sh\n"
+    const external = "# jobs, edited elsewhere\n@hourly /bin/true\n@daily /bin/sh\n"
+    const mine = loaded + "@weekly /bin/date\n"
+
+    // setup loads the crontab, lets something else write external unless
+    // it is empty and then adds a job.
+    setup := func(t *testing.T, external string) (source.Source, string, error) {
+        undoStack = &UndoStack{}
+        baselines = map[source.Source]baseline{}
+        path := filepath.Join(t.TempDir(), "crontab")
+        if err := os.WriteFile(path, []byte(loaded), 0644); err != nil {
+            t.Fatal(err)
+        }
+        src := source.NewFileSource(path)
+        setBaseline(src, []byte(loaded))
+        if external != "" {
+            if err := os.WriteFile(path, []byte(external), 0644); err != nil {
+                t.Fatal(err)
+            }
+        }
+        return src, path, AppendCrontabJob(src, []string{"@weekly"}, "", "/bin/date")
+    }
+    read := func(t *testing.T, path string) string {
+        data, err := os.ReadFile(path)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return string(data)
+    }
+    conflict := func(t *testing.T, err error) *ConflictError {
+        conflict, ok := err.(*ConflictError)
+        if !ok {
+            t.Fatalf("got %v, want a *ConflictError", err)
+        }
+        return conflict
+    }
+
+    t.Run("unchanged", func(t *testing.T) {
+        _, path, err := setup(t, "")
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := read(t, path); got != mine {
+            t.Errorf("crontab is %q, want %q", got, mine)
+        }
+        if undoStack.Len() != 1 {
+            t.Errorf("%d undo entries, want 1", undoStack.Len())
+        }
+    })
+
+    t.Run("changed", func(t *testing.T) {
+        _, path, err := setup(t, external)
+        c := conflict(t, err)
+        if string(c.Base) != loaded || string(c.Mine) != mine || string(c.Theirs) != external {
+            t.Errorf("conflict base %q, mine %q, theirs %q", c.Base, c.Mine, c.Theirs)
+        }
+        if got := read(t, path); got != external {
+            t.Errorf("crontab is %q, want it left at %q", got, external)
+        }
+        if undoStack.Len() != 0 {
+            t.Errorf("%d undo entries, want none", undoStack.Len())
+        }
+    })
+
+    t.Run("keep mine", func(t *testing.T) {
+        _, path, err := setup(t, external)
+        c := conflict(t, err)
+        if err := ResolveConflict(c, c.Mine); err != nil {
+            t.Fatal(err)
+        }
+        if got := read(t, path); got != mine {
+            t.Errorf("crontab is %q, want %q", got, mine)
+        }
+        if _, err := undoStack.Undo(); err != nil {
+            t.Fatal(err)
+        }
+        if got := read(t, path); got != external {
+            t.Errorf("crontab is %q after undo, want %q", got, external)
+        }
+    })
+
+    t.Run("take theirs", func(t *testing.T) {
+        src, path, err := setup(t, external)
+        conflict(t, err)
+        // Taking theirs writes nothing and reloads.
+        setBaseline(src, []byte(external))
+        if err := AppendCrontabJob(src, []string{"@monthly"}, "", "/bin/true"); err != nil {
+            t.Fatal(err)
+        }
+        if got, want := read(t, path), external+"@monthly /bin/true\n"; got != want {
+            t.Errorf("crontab is %q, want %q", got, want)
+        }
+    })
+
+    t.Run("merge", func(t *testing.T) {
+        _, path, err := setup(t, external)
+        c := conflict(t, err)
+        hunks := diff.Merge(diff.Lines(c.Base), diff.Lines(c.Mine), diff.Lines(c.Theirs))
+        merged, err := diff.Resolve(hunks)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if err := ResolveConflict(c, merged); err != nil {
+            t.Fatal(err)
+        }
+        if got, want := read(t, path), external+"@weekly /bin/date\n"; got != want {
+            t.Errorf("crontab is %q, want %q", got, want)
+        }
+    })
+
+    t.Run("changed again while merging", func(t *testing.T) {
+        _, path, err := setup(t, external)
+        c := conflict(t, err)
+        const again = "# edited twice\n"
+        if err := os.WriteFile(path, []byte(again), 0644); err != nil {
+            t.Fatal(err)
+        }
+        if c = conflict(t, ResolveConflict(c, c.Mine)); string(c.Theirs) != again {
+            t.Errorf("theirs is %q, want %q", c.Theirs, again)
+        }
+        if got := read(t, path); got != again {
+            t.Errorf("crontab is %q, want it left at %q", got, again)
+        }
+    })
+}
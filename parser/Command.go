@@ -0,0 +1,111 @@
+package parser
+
+import (
+    "strings"
+)
+
+// SplitCommand separates what cron passes to the shell from the standard
+// input it feeds the command, like cron's do_command: the first unescaped
+// % ends the command and the later ones become newlines of the input. \%
+// stands for a literal % in both parts, other backslashes are kept.
+// hasInput reports whether there was a % at all.
+func SplitCommand(command string) (run string, input string, hasInput bool) {
+    var b strings.Builder
+    for i := 0; i < len(command); i++ {
+        c := command[i]
+        switch {
+        case c == '\\' && i+1 < len(command):
+            if command[i+1] == '%' {
+                b.WriteByte('%')
+            } else {
+                b.WriteString(command[i : i+2])
+            }
+            i++
+        case c == '%':
+            return b.String(), commandInput(command[i+1:]), true
+        default:
+            b.WriteByte(c)
+        }
+    }
+    return b.String(), "", false
+}
+
+// commandInput translates the text after the first % the way cron's
+// child process writes it to the command's standard input.
+func commandInput(text string) string {
+    var b strings.Builder
+    escaped := false
+    for i := 0; i < len(text); i++ {
+        c := text[i]
+        if escaped {
+            if c != '%' {
+                b.WriteByte('\\')
+            }
+        } else if c == '%' {
+            c = '\n'
+        }
+        escaped = c == '\\'
+        if !escaped {
+            b.WriteByte(c)
+        }
+    }
+    if escaped {
+        b.WriteByte('\\')
+    }
+    return b.String()
+}
+
+// EscapePercent escapes the % signs cron would act on, keeping the whole
+// text in the command.
+func EscapePercent(command string) string {
+    var b strings.Builder
+    for i := 0; i < len(command); i++ {
+        c := command[i]
+        switch {
+        case c == '\\' && i+1 < len(command):
+            b.WriteString(command[i : i+2])
+            i++
+        case c == '%':
+            b.WriteString(`\%`)
+        default:
+            b.WriteByte(c)
+        }
+    }
+    return b.String()
+}
+
+// UnintendedPercent returns the index of the first unescaped % that reads
+// like a date or printf conversion, as in date +%Y or printf '%s\n', or -1.
+// Those were almost certainly meant for the command rather than to start
+// its input. A % followed by a word, as in mail -s hi ops%Hello, is not
+// reported.
+func UnintendedPercent(command string) int {
+    for i := 0; i < len(command); i++ {
+        switch command[i] {
+        case '\\':
+            i++
+        case '%':
+            if looksLikeConversion(command[i+1:]) {
+                return i
+            }
+        }
+    }
+    return -1
+}
+
+// looksLikeConversion reports whether text starts with optional flags and
+// width followed by a single letter, e.g. "Y", "-d", "02d" or "H:".
+func looksLikeConversion(text string) bool {
+    j := 0
+    for j < len(text) && strings.IndexByte("-_0^#.123456789", text[j]) >= 0 {
+        j++
+    }
+    if j == len(text) || !isLetter(text[j]) {
+        return false
+    }
+    return j+1 == len(text) || !isLetter(text[j+1])
+}
+
+func isLetter(c byte) bool {
+    return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
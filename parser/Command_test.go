@@ -0,0 +1,75 @@
+package parser
+
+import (
+    "testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+    tests := []struct {
+        command  string
+        run      string
+        input    string
+        hasInput bool
+    }{
+        {"/bin/backup --full", "/bin/backup --full", "", false},
+        {`date +\%Y-\%m-\%d`, "date +%Y-%m-%d", "", false},
+        {"mail -s report ops%Hello%world", "mail -s report ops", "Hello\nworld", true},
+        {`cat%100\% done%`, "cat", "100% done\n", true},
+        {"wc -l%", "wc -l", "", true},
+        {`echo a\\b`, `echo a\\b`, "", false},
+        {`echo \\%in`, `echo \\`, "in", true},
+        {`cat%a\b\`, "cat", `a\b\`, true},
+    }
+
+    for _, tt := range tests {
+        run, input, hasInput := SplitCommand(tt.command)
+        if run != tt.run || input != tt.input || hasInput != tt.hasInput {
+            t.Errorf("SplitCommand(%q) = %q, %q, %v, want %q, %q, %v", tt.command, run, input, hasInput, tt.run, tt.input, tt.hasInput)
+        }
+    }
+}
+
+func TestEscapePercent(t *testing.T) {
+    tests := []struct {
+        command string
+        want    string
+    }{
+        {"date +%Y-%m-%d", `date +\%Y-\%m-\%d`},
+        {`date +\%F`, `date +\%F`},
+        {`echo \\%x`, `echo \\\%x`},
+        {"/bin/true", "/bin/true"},
+    }
+
+    for _, tt := range tests {
+        got := EscapePercent(tt.command)
+        if got != tt.want {
+            t.Errorf("EscapePercent(%q) = %q, want %q", tt.command, got, tt.want)
+        }
+        if _, _, hasInput := SplitCommand(got); hasInput {
+            t.Errorf("EscapePercent(%q) still has input", tt.command)
+        }
+    }
+}
+
+func TestUnintendedPercent(t *testing.T) {
+    tests := []struct {
+        command string
+        want    int
+    }{
+        {"tar czf /backup/$(date +%F).tgz /home", 24},
+        {"date +%H:%M >> /tmp/log", 6},
+        {"printf '%s\\n' done", 8},
+        {"ls /var/log/%-d", 12},
+        {"echo %02d", 5},
+        {`date +\%Y-\%m`, -1},
+        {"mail -s report ops%Hello%world", -1},
+        {"wc -l%", -1},
+        {"/bin/true", -1},
+    }
+
+    for _, tt := range tests {
+        if got := UnintendedPercent(tt.command); got != tt.want {
+            t.Errorf("UnintendedPercent(%q) = %d, want %d", tt.command, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,464 @@
+package parser
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// How far ahead (or back) Next and Prev look before giving up. Anything
+// that can fire at all fires at least once in a 28 year calendar cycle.
+const searchYears = 30
+
+// dstWindow bounds how far a DST transition moves the clock. Within it the
+// order of wall clock times and of instants may differ.
+const dstWindow = 3 * time.Hour
+
+// CronSchedule is the compiled form of the five schedule fields. Each
+// field is a bitset where bit n is set when value n matches.
+type CronSchedule struct {
+    Minute  uint64
+    Hour    uint64
+    Dom     uint64
+    Month   uint64
+    Dow     uint64
+    Reboot  bool
+    domStar bool
+    dowStar bool
+    // A job with neither a * minute nor a * hour has a fixed time, cron
+    // treats those differently around DST transitions.
+    minuteStar bool
+    hourStar   bool
+}
+
+var specialSchedules = map[string][]string{
+    "@yearly":   {"0", "0", "1", "1", "*"},
+    "@annually": {"0", "0", "1", "1", "*"},
+    "@monthly":  {"0", "0", "1", "*", "*"},
+    "@weekly":   {"0", "0", "*", "*", "0"},
+    "@daily":    {"0", "0", "*", "*", "*"},
+    "@midnight": {"0", "0", "*", "*", "*"},
+    "@hourly":   {"0", "*", "*", "*", "*"},
+}
+
+// ParseSchedule compiles either a single @-special or the five classic
+// fields (minute, hour, day of month, month, day of week).
+func ParseSchedule(fields []string) (*CronSchedule, error) {
+    if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+        special := strings.ToLower(fields[0])
+        if special == "@reboot" {
+            return &CronSchedule{Reboot: true}, nil
+        }
+        expanded, ok := specialSchedules[special]
+        if !ok {
+            return nil, fmt.Errorf("unknown special schedule '%s'", fields[0])
+        }
+        fields = expanded
+    }
+    if len(fields) != 5 {
+        return nil, fmt.Errorf("schedule must have 5 fields")
+    }
+
+    bits := make([]uint64, 5)
+    for i, field := range fields {
+        b, err := parseField(field, cronRanges[i])
+        if err != nil {
+            return nil, fmt.Errorf("%s field '%s' invalid: %v", cronRanges[i].name, field, err)
+        }
+        bits[i] = b
+    }
+
+    // Sunday can be written as 0 or 7.
+    if bits[4]&(1<<7) != 0 {
+        bits[4] |= 1
+        bits[4] &^= 1 << 7
+    }
+
+    return &CronSchedule{
+        Minute:  bits[0],
+        Hour:    bits[1],
+        Dom:     bits[2],
+        Month:   bits[3],
+        Dow:     bits[4],
+        domStar: strings.HasPrefix(fields[2], "*"),
+        dowStar: strings.HasPrefix(fields[4], "*"),
+        minuteStar: strings.HasPrefix(fields[0], "*"),
+        hourStar:   strings.HasPrefix(fields[1], "*"),
+    }, nil
+}
+
+func parseField(field string, r fieldRange) (uint64, error) {
+    if field == "" {
+        return 0, fmt.Errorf("empty field")
+    }
+
+    var bits uint64
+    parts := strings.Split(field, ",")
+    for _, p := range parts {
+        b, err := parseFieldPart(p, r)
+        if err != nil {
+            return 0, err
+        }
+        bits |= b
+    }
+    return bits, nil
+}
+
+// parseFieldPart parses one list element: *, a value or a range, each
+// optionally followed by /step. A single value with a step (5/15) runs
+// from that value to the end of the field's range.
+func parseFieldPart(p string, r fieldRange) (uint64, error) {
+    if p == "" {
+        return 0, fmt.Errorf("empty list element")
+    }
+
+    base, stepStr, stepped := strings.Cut(p, "/")
+    step := 1
+    if stepped {
+        n, err := strconv.Atoi(stepStr)
+        if err != nil || n <= 0 {
+            return 0, fmt.Errorf("invalid step value in '%s'", p)
+        }
+        step = n
+    }
+
+    start, end := r.min, r.max
+    switch {
+    case base == "*":
+    case strings.Contains(base, "-"):
+        bounds := strings.Split(base, "-")
+        if len(bounds) != 2 {
+            return 0, fmt.Errorf("invalid range '%s'", p)
+        }
+        var err1, err2 error
+        start, err1 = parseValue(bounds[0], r)
+        end, err2 = parseValue(bounds[1], r)
+        if err1 != nil || err2 != nil {
+            return 0, fmt.Errorf("invalid range values in '%s'", p)
+        }
+        if start < r.min || end > r.max || start > end {
+            return 0, fmt.Errorf("range out of bounds (%d-%d) in '%s'", r.min, r.max, p)
+        }
+    default:
+        val, err := parseValue(base, r)
+        if err != nil {
+            return 0, err
+        }
+        if val < r.min || val > r.max {
+            return 0, fmt.Errorf("value %d out of range (%d-%d)", val, r.min, r.max)
+        }
+        start = val
+        if !stepped {
+            end = val
+        }
+    }
+    return bitRange(start, end, step), nil
+}
+
+// parseValue accepts a number or, for month and day of week, a
+// case-insensitive three letter name such as JAN or mon.
+func parseValue(token string, r fieldRange) (int, error) {
+    if val, err := strconv.Atoi(token); err == nil {
+        return val, nil
+    }
+    if val, ok := r.names[strings.ToUpper(token)]; ok {
+        return val, nil
+    }
+    if r.names != nil {
+        return 0, fmt.Errorf("invalid value '%s' (expected a number or name)", token)
+    }
+    return 0, fmt.Errorf("invalid integer '%s'", token)
+}
+
+func bitRange(start, end, step int) uint64 {
+    var bits uint64
+    for i := start; i <= end; i += step {
+        bits |= 1 << uint(i)
+    }
+    return bits
+}
+
+func hasBit(bits uint64, n int) bool {
+    return bits&(1<<uint(n)) != 0
+}
+
+// dayMatches implements Vixie cron's rule: when both day of month and day
+// of week are restricted the job runs when either matches, otherwise both
+// have to match.
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+    dom := hasBit(s.Dom, t.Day())
+    dow := hasBit(s.Dow, int(t.Weekday()))
+    if s.domStar || s.dowStar {
+        return dom && dow
+    }
+    return dom || dow
+}
+
+// Matches reports whether the schedule fires in the minute containing t.
+func (s *CronSchedule) Matches(t time.Time) bool {
+    if s == nil || s.Reboot {
+        return false
+    }
+    return hasBit(s.Minute, t.Minute()) &&
+        hasBit(s.Hour, t.Hour()) &&
+        hasBit(s.Month, int(t.Month())) &&
+        s.dayMatches(t)
+}
+
+// Next returns the first fire time strictly after the given time, in its
+// location, or the zero time if the schedule never fires (@reboot, Feb 30,
+// ...).
+//
+// Around DST transitions it behaves like cron: a fixed time job whose time
+// is skipped when the clock goes forward runs once right after the jump,
+// one whose time occurs twice when the clock goes back runs the first time
+// only. Jobs with a * minute or hour follow the clock as it is.
+func (s *CronSchedule) Next(after time.Time) time.Time {
+    if s == nil || s.Reboot {
+        return time.Time{}
+    }
+    loc := after.Location()
+    // Wall clock times are walked in UTC, where the calendar arithmetic has
+    // no gaps, and mapped to instants in loc one by one. Times up to
+    // dstWindow before the clock of after may still map to a later instant.
+    from := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, time.UTC).Add(-dstWindow)
+    day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+    end := day.AddDate(searchYears, 0, 0)
+    var best, bestClock time.Time
+    for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+        if !hasBit(s.Month, int(day.Month())) || !s.dayMatches(day) {
+            continue
+        }
+        for h := 0; h < 24; h++ {
+            if !hasBit(s.Hour, h) {
+                continue
+            }
+            for m := 0; m < 60; m++ {
+                if !hasBit(s.Minute, m) {
+                    continue
+                }
+                clock := day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+                if clock.Before(from) {
+                    continue
+                }
+                if !best.IsZero() && clock.After(bestClock.Add(dstWindow)) {
+                    return best
+                }
+                for _, t := range s.fireTimes(clock, loc) {
+                    if t.After(after) && (best.IsZero() || t.Before(best)) {
+                        best, bestClock = t, clock
+                    }
+                }
+                // Without a transition nearby no later clock maps to an
+                // earlier instant.
+                if !best.IsZero() && !nearTransition(best) {
+                    return best
+                }
+            }
+        }
+    }
+    return best
+}
+
+// fireTimes returns the instants in loc at which the schedule fires for a
+// matching wall clock time, given in UTC.
+func (s *CronSchedule) fireTimes(clock time.Time, loc *time.Location) []time.Time {
+    times := wallClock(clock, loc)
+    fixed := !s.minuteStar && !s.hourStar
+    switch {
+    case len(times) == 0 && fixed:
+        return []time.Time{clockJump(clock, loc)}
+    case len(times) > 1 && fixed:
+        return times[:1]
+    }
+    return times
+}
+
+// wallClock returns the instants at which the clock in loc shows the given
+// wall clock time: usually one, none in a DST gap and two in an overlap.
+func wallClock(clock time.Time, loc *time.Location) []time.Time {
+    _, offset := clock.In(loc).Zone()
+    approx := clock.Add(-time.Duration(offset) * time.Second)
+    var times []time.Time
+    for _, probe := range []time.Time{approx.Add(-dstWindow), approx, approx.Add(dstWindow)} {
+        _, offset := probe.In(loc).Zone()
+        t := clock.Add(-time.Duration(offset) * time.Second).In(loc)
+        if !sameClock(t, clock) {
+            continue
+        }
+        seen := false
+        for _, other := range times {
+            seen = seen || other.Equal(t)
+        }
+        if !seen {
+            times = append(times, t)
+        }
+    }
+    sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+    return times
+}
+
+// clockJump returns the first instant whose wall clock in loc is past the
+// given one, for a time skipped by a DST gap: the moment of the jump.
+func clockJump(clock time.Time, loc *time.Location) time.Time {
+    _, offset := clock.In(loc).Zone()
+    lo := clock.Add(-time.Duration(offset)*time.Second - dstWindow)
+    seconds := int(2 * dstWindow / time.Second)
+    i := sort.Search(seconds, func(i int) bool {
+        t := lo.Add(time.Duration(i) * time.Second).In(loc)
+        return wallTime(t).After(clock)
+    })
+    return lo.Add(time.Duration(i) * time.Second).In(loc)
+}
+
+// wallTime is the wall clock of t as a UTC time.
+func wallTime(t time.Time) time.Time {
+    return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+func sameClock(t, clock time.Time) bool {
+    return wallTime(t).Equal(clock)
+}
+
+// nearTransition reports whether t's location changes its UTC offset
+// within dstWindow of t.
+func nearTransition(t time.Time) bool {
+    _, before := t.Add(-dstWindow).Zone()
+    _, after := t.Add(dstWindow).Zone()
+    return before != after
+}
+
+// Prev returns the last fire time strictly before the given time, or the
+// zero time if there is none. It follows cron around DST transitions the
+// same way Next does.
+func (s *CronSchedule) Prev(before time.Time) time.Time {
+    if s == nil || s.Reboot {
+        return time.Time{}
+    }
+    loc := before.Location()
+    // The walk of Next backwards: times up to dstWindow after the clock of
+    // before may still map to an earlier instant.
+    from := time.Date(before.Year(), before.Month(), before.Day(), before.Hour(), before.Minute(), 0, 0, time.UTC).Add(dstWindow)
+    day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+    end := day.AddDate(-searchYears, 0, 0)
+    var best, bestClock time.Time
+    for ; day.After(end); day = day.AddDate(0, 0, -1) {
+        if !hasBit(s.Month, int(day.Month())) || !s.dayMatches(day) {
+            continue
+        }
+        for h := 23; h >= 0; h-- {
+            if !hasBit(s.Hour, h) {
+                continue
+            }
+            for m := 59; m >= 0; m-- {
+                if !hasBit(s.Minute, m) {
+                    continue
+                }
+                clock := day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+                if clock.After(from) {
+                    continue
+                }
+                if !best.IsZero() && clock.Before(bestClock.Add(-dstWindow)) {
+                    return best
+                }
+                for _, t := range s.fireTimes(clock, loc) {
+                    if t.Before(before) && (best.IsZero() || t.After(best)) {
+                        best, bestClock = t, clock
+                    }
+                }
+                if !best.IsZero() && !nearTransition(best) {
+                    return best
+                }
+            }
+        }
+    }
+    return best
+}
+
+// Overlap returns the first instant after the given time at which both
+// schedules fire, or the zero time if they never fire together. Fire times
+// are those of Next, so around a DST change a job moved to the end of a
+// gap can meet one that runs at that time.
+func (s *CronSchedule) Overlap(o *CronSchedule, after time.Time) time.Time {
+    if s == nil || o == nil || s.Reboot || o.Reboot {
+        return time.Time{}
+    }
+    loc := after.Location()
+    from := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, time.UTC).Add(-dstWindow)
+    day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+    end := day.AddDate(searchYears, 0, 0)
+    var best, bestClock time.Time
+    for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+        if !hasBit(s.Month, int(day.Month())) || !s.dayMatches(day) {
+            continue
+        }
+        // Away from DST changes both fire at the clock they match, so only
+        // clocks matching both schedules are tried.
+        changes := offsetChanges(day, loc)
+        if !changes && (!hasBit(o.Month, int(day.Month())) || !o.dayMatches(day)) {
+            continue
+        }
+        for h := 0; h < 24; h++ {
+            if !hasBit(s.Hour, h) || !changes && !hasBit(o.Hour, h) {
+                continue
+            }
+            for m := 0; m < 60; m++ {
+                if !hasBit(s.Minute, m) || !changes && !hasBit(o.Minute, m) {
+                    continue
+                }
+                clock := day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+                if clock.Before(from) {
+                    continue
+                }
+                if !best.IsZero() && clock.After(bestClock.Add(dstWindow)) {
+                    return best
+                }
+                for _, t := range s.fireTimes(clock, loc) {
+                    if t.After(after) && (best.IsZero() || t.Before(best)) && o.firesAt(t) {
+                        best, bestClock = t, clock
+                    }
+                }
+                if !best.IsZero() && !nearTransition(best) {
+                    return best
+                }
+            }
+        }
+    }
+    return best
+}
+
+// firesAt reports whether the schedule fires at the instant t.
+func (s *CronSchedule) firesAt(t time.Time) bool {
+    if !nearTransition(t) {
+        return s.Matches(t)
+    }
+    return s.Next(t.Add(-time.Second)).Equal(t)
+}
+
+// offsetChanges reports whether loc changes its UTC offset anywhere near
+// the wall clock day given in UTC, whatever the zone's offset.
+func offsetChanges(day time.Time, loc *time.Location) bool {
+    _, start := day.Add(-14*time.Hour - dstWindow).In(loc).Zone()
+    _, end := day.Add(38*time.Hour + dstWindow).In(loc).Zone()
+    return start != end
+}
+
+// NextN returns up to n fire times after the given time, nil when n is
+// not positive.
+func (s *CronSchedule) NextN(after time.Time, n int) []time.Time {
+    if n <= 0 {
+        return nil
+    }
+    times := make([]time.Time, 0, n)
+    for i := 0; i < n; i++ {
+        next := s.Next(after)
+        if next.IsZero() {
+            break
+        }
+        times = append(times, next)
+        after = next
+    }
+    return times
+}
@@ -0,0 +1,202 @@
+package parser
+
+import (
+    "reflect"
+    "testing"
+    "time"
+)
+
+func bitValues(bits uint64) []int {
+    values := []int{}
+    for i := 0; i < 64; i++ {
+        if hasBit(bits, i) {
+            values = append(values, i)
+        }
+    }
+    return values
+}
+
+func TestParseField(t *testing.T) {
+    tests := []struct {
+        field   string
+        index   int
+        want    []int
+        wantErr bool
+    }{
+        {"*", 3, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, false},
+        {"*/15", 0, []int{0, 15, 30, 45}, false},
+        {"0-30/5", 0, []int{0, 5, 10, 15, 20, 25, 30}, false},
+        {"8-18/2", 1, []int{8, 10, 12, 14, 16, 18}, false},
+        {"10-50/10,55", 0, []int{10, 20, 30, 40, 50, 55}, false},
+        {"1-5/2,20-22", 2, []int{1, 3, 5, 20, 21, 22}, false},
+        {"5/15", 0, []int{5, 20, 35, 50}, false},
+        {"*/1", 1, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}, false},
+        {"MON-FRI/2", 4, []int{1, 3, 5}, false},
+        {"jan-dec/3", 3, []int{1, 4, 7, 10}, false},
+
+        // Step larger than the range only matches the start.
+        {"0-10/20", 0, []int{0}, false},
+        {"*/100", 0, []int{0}, false},
+        {"5-5/3", 1, []int{5}, false},
+
+        {"*/0", 0, nil, true},
+        {"1-5/0", 0, nil, true},
+        {"0-30/", 0, nil, true},
+        {"*/", 0, nil, true},
+        {"/5", 0, nil, true},
+        {"1-5/2/3", 0, nil, true},
+        {"*/-1", 0, nil, true},
+        {"30-10/5", 0, nil, true},
+        {"0-60/5", 0, nil, true},
+        {"5,", 0, nil, true},
+        {",5", 0, nil, true},
+        {"1-2-3", 0, nil, true},
+        {"", 0, nil, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.field, func(t *testing.T) {
+            bits, err := parseField(tt.field, cronRanges[tt.index])
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("expected error, got %v", bitValues(bits))
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := bitValues(bits); !reflect.DeepEqual(got, tt.want) {
+                t.Fatalf("got %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestScheduleNext(t *testing.T) {
+    // A Sunday.
+    base := time.Date(2026, 10, 18, 12, 34, 56, 0, time.UTC)
+
+    tests := []struct {
+        name   string
+        fields []string
+        after  time.Time
+        want   time.Time
+    }{
+        {"every minute", []string{"*", "*", "*", "*", "*"}, base, time.Date(2026, 10, 18, 12, 35, 0, 0, time.UTC)},
+        {"strictly after", []string{"35", "12", "*", "*", "*"}, time.Date(2026, 10, 18, 12, 35, 0, 0, time.UTC), time.Date(2026, 10, 19, 12, 35, 0, 0, time.UTC)},
+        {"minute step", []string{"*/15", "*", "*", "*", "*"}, base, time.Date(2026, 10, 18, 12, 45, 0, 0, time.UTC)},
+        {"range step", []string{"0-30/10", "8-18/2", "*", "*", "*"}, time.Date(2026, 10, 18, 18, 31, 0, 0, time.UTC), time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)},
+        {"list step", []string{"10-50/10,55", "*", "*", "*", "*"}, time.Date(2026, 10, 18, 12, 51, 0, 0, time.UTC), time.Date(2026, 10, 18, 12, 55, 0, 0, time.UTC)},
+        {"value step", []string{"0", "5/6", "*", "*", "*"}, base, time.Date(2026, 10, 18, 17, 0, 0, 0, time.UTC)},
+        {"weekday names", []string{"0", "9", "*", "*", "MON-FRI"}, base, time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+        {"dom or dow", []string{"0", "0", "13", "*", "5"}, base, time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)},
+        {"dom only", []string{"0", "0", "13", "*", "*"}, base, time.Date(2026, 11, 13, 0, 0, 0, 0, time.UTC)},
+        {"dom star step and dow", []string{"0", "0", "*/2", "*", "2"}, base, time.Date(2026, 10, 27, 0, 0, 0, 0, time.UTC)},
+        {"sunday as 7", []string{"0", "0", "*", "*", "7"}, base, time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+        {"year wrap", []string{"0", "0", "1", "JAN", "*"}, base, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+        {"leap day", []string{"0", "0", "29", "2", "*"}, base, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+        {"never", []string{"0", "0", "30", "2", "*"}, base, time.Time{}},
+        {"special", []string{"@weekly"}, base, time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+        {"reboot", []string{"@reboot"}, base, time.Time{}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s, err := ParseSchedule(tt.fields)
+            if err != nil {
+                t.Fatalf("parse: %v", err)
+            }
+            if got := s.Next(tt.after); !got.Equal(tt.want) {
+                t.Fatalf("Next(%v) = %v, want %v", tt.after, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSchedulePrev(t *testing.T) {
+    base := time.Date(2026, 10, 18, 12, 34, 56, 0, time.UTC)
+
+    tests := []struct {
+        name   string
+        fields []string
+        want   time.Time
+    }{
+        {"minute step", []string{"*/15", "*", "*", "*", "*"}, time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)},
+        {"range step", []string{"0-30/10", "8-18/2", "*", "*", "*"}, time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)},
+        {"weekdays", []string{"0", "9", "*", "*", "MON-FRI"}, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+        {"month wrap", []string{"0", "0", "1", "DEC", "*"}, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+        {"never", []string{"0", "0", "31", "4", "*"}, time.Time{}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s, err := ParseSchedule(tt.fields)
+            if err != nil {
+                t.Fatalf("parse: %v", err)
+            }
+            if got := s.Prev(base); !got.Equal(tt.want) {
+                t.Fatalf("Prev = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestScheduleNextN(t *testing.T) {
+    s, err := ParseSchedule([]string{"*/20", "*", "*", "*", "*"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    base := time.Date(2026, 10, 18, 12, 34, 56, 0, time.UTC)
+    want := []time.Time{
+        time.Date(2026, 10, 18, 12, 40, 0, 0, time.UTC),
+        time.Date(2026, 10, 18, 13, 0, 0, 0, time.UTC),
+        time.Date(2026, 10, 18, 13, 20, 0, 0, time.UTC),
+    }
+    if got := s.NextN(base, 3); !reflect.DeepEqual(got, want) {
+        t.Errorf("NextN(3) = %v, want %v", got, want)
+    }
+    for _, n := range []int{0, -3} {
+        if got := s.NextN(base, n); got != nil {
+            t.Errorf("NextN(%d) = %v, want nil", n, got)
+        }
+    }
+}
+
+func TestDescribeScheduleMidnight(t *testing.T) {
+    tests := []struct {
+        fields []string
+        want   string
+    }{
+        {[]string{"0", "0", "*", "*", "*"}, "Every day at midnight"},
+        {[]string{"0", "0", "*", "*", "1-5"}, "Every weekday at midnight"},
+    }
+
+    for _, tt := range tests {
+        if got := DescribeSchedule(tt.fields); got != tt.want {
+            t.Errorf("DescribeSchedule(%v) = %q, want %q", tt.fields, got, tt.want)
+        }
+    }
+}
+
+func TestDescribeScheduleSteps(t *testing.T) {
+    tests := []struct {
+        fields []string
+        want   string
+    }{
+        {[]string{"*/5", "*", "*", "*", "*"}, "Every 5 minutes"},
+        {[]string{"0-30/5", "*", "*", "*", "*"}, "every 5 minutes from minute 0 through minute 30"},
+        {[]string{"10-50/10,55", "*", "*", "*", "*"}, "every 10 minutes from minute 10 through minute 50 and minute 55"},
+        {[]string{"0", "8-18/2", "*", "*", "*"}, "at minute 0 during every 2 hours from 8:00 AM through 6:00 PM"},
+        {[]string{"*/15", "9-17", "*", "*", "MON-FRI"}, "every 15 minutes during 9:00 AM through 5:00 PM on Monday through Friday"},
+        {[]string{"0", "0", "1-31/2", "*", "*"}, "at 12:00 AM on every 2 days from the 1st through the 31st"},
+        {[]string{"0", "12", "1", "*/3", "*"}, "at 12:00 PM on the 1st in every 3 months"},
+        {[]string{"5", "*", "*", "*", "*"}, "Every hour at :05"},
+    }
+
+    for _, tt := range tests {
+        if got := DescribeSchedule(tt.fields); got != tt.want {
+            t.Errorf("DescribeSchedule(%v) = %q, want %q", tt.fields, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// CronWatcher reports changes to a single crontab file. It watches the
+// parent directory so that editors (and `crontab -e`) replacing the file
+// through a temp file + rename are noticed, as well as truncation and
+// deletion followed by recreation.
+type CronWatcher struct {
+    path     string
+    interval time.Duration
+    callback func()
+    cancel   context.CancelFunc
+    done     chan struct{}
+    last     fileSnapshot
+}
+
+type fileSnapshot struct {
+    exists  bool
+    info    os.FileInfo
+    size    int64
+    modTime time.Time
+    sum     [sha256.Size]byte
+}
+
+func takeSnapshot(path string) fileSnapshot {
+    info, err := os.Stat(path)
+    if err != nil {
+        return fileSnapshot{}
+    }
+    snap := fileSnapshot{exists: true, info: info, size: info.Size(), modTime: info.ModTime()}
+    if data, err := os.ReadFile(path); err == nil {
+        snap.sum = sha256.Sum256(data)
+    }
+    return snap
+}
+
+func (s fileSnapshot) equal(o fileSnapshot) bool {
+    if s.exists != o.exists {
+        return false
+    }
+    if !s.exists {
+        return true
+    }
+    return os.SameFile(s.info, o.info) &&
+        s.size == o.size &&
+        s.modTime.Equal(o.modTime) &&
+        bytes.Equal(s.sum[:], o.sum[:])
+}
+
+// WatchCronFile calls callback every time path changes. interval is used
+// both as the debounce window for bursts of writes and as the polling
+// period when inotify is not available.
+func WatchCronFile(path string, interval time.Duration, callback func()) (*CronWatcher, error) {
+    return WatchCronFileContext(context.Background(), path, interval, callback)
+}
+
+// WatchCronFileContext is like WatchCronFile but stops when ctx is done.
+func WatchCronFileContext(ctx context.Context, path string, interval time.Duration, callback func()) (*CronWatcher, error) {
+    return watchCronFile(ctx, path, interval, callback, false)
+}
+
+func watchCronFile(ctx context.Context, path string, interval time.Duration, callback func(), forcePoll bool) (*CronWatcher, error) {
+    if interval <= 0 {
+        interval = time.Second
+    }
+    abs, err := filepath.Abs(path)
+    if err != nil {
+        return nil, err
+    }
+
+    ctx, cancel := context.WithCancel(ctx)
+    w := &CronWatcher{
+        path:     abs,
+        interval: interval,
+        callback: callback,
+        cancel:   cancel,
+        done:     make(chan struct{}),
+        last:     takeSnapshot(abs),
+    }
+
+    var events <-chan struct{}
+    if !forcePoll {
+        // A failure here is not fatal, we just fall back to polling.
+        events, _ = startNotify(ctx, abs)
+    }
+    go w.run(ctx, events)
+    return w, nil
+}
+
+// Stop ends the watch and waits until no further callbacks can happen.
+func (w *CronWatcher) Stop() {
+    w.cancel()
+    <-w.done
+}
+
+// Path returns the absolute path being watched.
+func (w *CronWatcher) Path() string {
+    return w.path
+}
+
+func (w *CronWatcher) run(ctx context.Context, events <-chan struct{}) {
+    defer close(w.done)
+
+    var tick <-chan time.Time
+    if events == nil {
+        ticker := time.NewTicker(w.interval)
+        defer ticker.Stop()
+        tick = ticker.C
+    }
+
+    debounce := time.NewTimer(w.interval)
+    if !debounce.Stop() {
+        <-debounce.C
+    }
+    defer debounce.Stop()
+
+    var pending *fileSnapshot
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case _, ok := <-events:
+            if !ok {
+                // The kernel dropped our watch (e.g. the directory went
+                // away), keep going by polling.
+                events = nil
+                ticker := time.NewTicker(w.interval)
+                defer ticker.Stop()
+                tick = ticker.C
+                continue
+            }
+            debounce.Reset(w.interval)
+        case <-debounce.C:
+            w.check(ctx)
+        case <-tick:
+            // When polling, only report once the file has been stable for
+            // a whole interval so that bursts collapse into one callback.
+            snap := takeSnapshot(w.path)
+            if snap.equal(w.last) {
+                pending = nil
+                continue
+            }
+            if pending != nil && snap.equal(*pending) {
+                pending = nil
+                w.fire(ctx, snap)
+                continue
+            }
+            pending = &snap
+        }
+    }
+}
+
+func (w *CronWatcher) check(ctx context.Context) {
+    snap := takeSnapshot(w.path)
+    if snap.equal(w.last) {
+        return
+    }
+    w.fire(ctx, snap)
+}
+
+func (w *CronWatcher) fire(ctx context.Context, snap fileSnapshot) {
+    w.last = snap
+    if ctx.Err() != nil || w.callback == nil {
+        return
+    }
+    w.callback()
+}
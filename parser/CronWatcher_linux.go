@@ -0,0 +1,76 @@
+//go:build linux
+
+package parser
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "strings"
+    "syscall"
+    "unsafe"
+)
+
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE |
+    syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+    syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// startNotify watches the directory containing path and sends on the
+// returned channel whenever an event concerns the file. The channel is
+// closed when the watch ends.
+func startNotify(ctx context.Context, path string) (<-chan struct{}, error) {
+    fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), inotifyMask); err != nil {
+        syscall.Close(fd)
+        return nil, err
+    }
+
+    // The fd is non-blocking so os.File hands it to the runtime poller and
+    // Close unblocks a pending Read.
+    file := os.NewFile(uintptr(fd), "inotify")
+    name := filepath.Base(path)
+    events := make(chan struct{}, 1)
+
+    go func() {
+        <-ctx.Done()
+        file.Close()
+    }()
+
+    go func() {
+        defer close(events)
+        buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+        for {
+            n, err := file.Read(buf)
+            if err != nil {
+                return
+            }
+            ignored := false
+            for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+                ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+                start := off + syscall.SizeofInotifyEvent
+                end := start + int(ev.Len)
+                off = end
+                if end > n {
+                    break
+                }
+                evName := strings.TrimRight(string(buf[start:end]), "\x00")
+                if ev.Mask&syscall.IN_IGNORED != 0 {
+                    ignored = true
+                }
+                if evName == name || ev.Mask&(syscall.IN_Q_OVERFLOW|syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 {
+                    select {
+                    case events <- struct{}{}:
+                    default:
+                    }
+                }
+            }
+            if ignored {
+                return
+            }
+        }
+    }()
+    return events, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package parser
+
+import (
+    "context"
+    "errors"
+)
+
+func startNotify(ctx context.Context, path string) (<-chan struct{}, error) {
+    return nil, errors.New("file notifications not supported on this platform")
+}
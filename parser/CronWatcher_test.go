@@ -0,0 +1,173 @@
+package parser
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+const testInterval = 50 * time.Millisecond
+
+type watchHarness struct {
+    t       *testing.T
+    path    string
+    calls   chan struct{}
+    count   atomic.Int32
+    watcher *CronWatcher
+}
+
+func newWatchHarness(t *testing.T, forcePoll bool) *watchHarness {
+    t.Helper()
+    dir := t.TempDir()
+    h := &watchHarness{
+        t:     t,
+        path:  filepath.Join(dir, "crontab"),
+        calls: make(chan struct{}, 100),
+    }
+    h.write("0 * * * * /bin/true\n")
+    w, err := watchCronFile(context.Background(), h.path, testInterval, func() {
+        h.count.Add(1)
+        h.calls <- struct{}{}
+    }, forcePoll)
+    if err != nil {
+        t.Fatalf("watch: %v", err)
+    }
+    h.watcher = w
+    t.Cleanup(w.Stop)
+    return h
+}
+
+func (h *watchHarness) write(content string) {
+    h.t.Helper()
+    if err := os.WriteFile(h.path, []byte(content), 0644); err != nil {
+        h.t.Fatalf("write: %v", err)
+    }
+}
+
+func (h *watchHarness) expectCall() {
+    h.t.Helper()
+    select {
+    case <-h.calls:
+    case <-time.After(2 * time.Second):
+        h.t.Fatalf("expected a change notification")
+    }
+}
+
+func (h *watchHarness) expectNoCall(wait time.Duration) {
+    h.t.Helper()
+    select {
+    case <-h.calls:
+        h.t.Fatalf("unexpected change notification")
+    case <-time.After(wait):
+    }
+}
+
+func forEachBackend(t *testing.T, fn func(t *testing.T, h *watchHarness)) {
+    for _, tc := range []struct {
+        name string
+        poll bool
+    }{
+        {"notify", false},
+        {"poll", true},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            fn(t, newWatchHarness(t, tc.poll))
+        })
+    }
+}
+
+func TestWatchModification(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, h *watchHarness) {
+        h.write("*/5 * * * * /bin/true\n")
+        h.expectCall()
+    })
+}
+
+func TestWatchAtomicRename(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, h *watchHarness) {
+        tmp := h.path + ".tmp"
+        if err := os.WriteFile(tmp, []byte("1 2 3 4 5 /bin/true\n"), 0644); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.Rename(tmp, h.path); err != nil {
+            t.Fatal(err)
+        }
+        h.expectCall()
+    })
+}
+
+func TestWatchTruncate(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, h *watchHarness) {
+        if err := os.Truncate(h.path, 0); err != nil {
+            t.Fatal(err)
+        }
+        h.expectCall()
+    })
+}
+
+func TestWatchDeleteAndRecreate(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, h *watchHarness) {
+        if err := os.Remove(h.path); err != nil {
+            t.Fatal(err)
+        }
+        h.expectCall()
+        h.write("@daily /bin/true\n")
+        h.expectCall()
+    })
+}
+
+func TestWatchDebounce(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, h *watchHarness) {
+        const writes = 10
+        for i := 0; i < writes; i++ {
+            h.write(string(rune('a'+i)) + "\n")
+            time.Sleep(testInterval / 10)
+        }
+        h.expectCall()
+        time.Sleep(4 * testInterval)
+        if n := h.count.Load(); n >= writes/2 {
+            t.Fatalf("burst of %d writes produced %d callbacks", writes, n)
+        }
+    })
+}
+
+func TestWatchUnchangedContent(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, h *watchHarness) {
+        h.expectNoCall(4 * testInterval)
+    })
+}
+
+func TestWatchStop(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, h *watchHarness) {
+        h.watcher.Stop()
+        h.write("changed after stop\n")
+        h.expectNoCall(4 * testInterval)
+    })
+}
+
+func TestWatchContextCancel(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "crontab")
+    if err := os.WriteFile(path, []byte("\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    calls := make(chan struct{}, 10)
+    w, err := WatchCronFileContext(ctx, path, testInterval, func() { calls <- struct{}{} })
+    if err != nil {
+        t.Fatal(err)
+    }
+    cancel()
+    w.Stop()
+    if err := os.WriteFile(path, []byte("changed\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case <-calls:
+        t.Fatalf("callback after context cancel")
+    case <-time.After(4 * testInterval):
+    }
+}
@@ -0,0 +1,423 @@
+package parser
+
+import (
+    "bytes"
+    "fmt"
+    "os"
+    "strings"
+)
+
+type LineKind int
+
+const (
+    LineBlank LineKind = iota
+    LineComment
+    LineEnv
+    LineJob
+    LineInvalid
+)
+
+func (k LineKind) String() string {
+    switch k {
+    case LineBlank:
+        return "blank"
+    case LineComment:
+        return "comment"
+    case LineEnv:
+        return "env"
+    case LineJob:
+        return "job"
+    case LineInvalid:
+        return "invalid"
+    }
+    return "unknown"
+}
+
+// Format tells whether lines carry a user column. User crontabs (crontab -l,
+// the spool files) do not, /etc/crontab and /etc/cron.d/* do.
+type Format int
+
+const (
+    UserFormat Format = iota
+    SystemFormat
+)
+
+func (f Format) String() string {
+    if f == SystemFormat {
+        return "system"
+    }
+    return "user"
+}
+
+type EnvVar struct {
+    Name  string
+    Value string
+}
+
+// Line is one physical line of a crontab. Text never contains the line
+// terminator, which is kept in EOL so the file can be written back
+// unchanged.
+type Line struct {
+    Kind   LineKind
+    Number int
+    Text   string
+    EOL    string
+    Job    *CronJob
+    Env    *EnvVar
+    Err    error
+}
+
+// Document is a crontab file as a list of typed lines. Bytes reproduces
+// the original input exactly as long as no line was modified.
+type Document struct {
+    Lines  []*Line
+    Format Format
+}
+
+func LoadDocument(path string) (*Document, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open file: %w", err)
+    }
+    return ParseDocument(data), nil
+}
+
+func ParseDocument(data []byte) *Document {
+    return ParseDocumentFormat(data, UserFormat)
+}
+
+func ParseDocumentFormat(data []byte, format Format) *Document {
+    doc := &Document{Format: format}
+    for len(data) > 0 {
+        text, eol := data, []byte(nil)
+        if i := bytes.IndexByte(data, '\n'); i >= 0 {
+            text, eol = data[:i], data[i:i+1]
+            if bytes.HasSuffix(text, []byte("\r")) {
+                text, eol = text[:len(text)-1], data[i-1:i+1]
+            }
+            data = data[i+1:]
+        } else {
+            data = nil
+        }
+        line := parseLine(string(text), format)
+        line.EOL = string(eol)
+        doc.Lines = append(doc.Lines, line)
+    }
+    doc.renumber()
+    return doc
+}
+
+// parseLine classifies a single line of text.
+func parseLine(raw string, format Format) *Line {
+    line := &Line{Text: raw}
+    trim := strings.TrimSpace(raw)
+    if trim == "" {
+        line.Kind = LineBlank
+        return line
+    }
+    if strings.HasPrefix(trim, "#") {
+        // A commented out line that still parses as a job is a job that
+        // was disabled, anything else is an ordinary comment.
+        if job := parseDisabledJob(raw, format); job != nil {
+            line.Kind = LineJob
+            line.Job = job
+            return line
+        }
+        line.Kind = LineComment
+        return line
+    }
+
+    if env, ok := ParseEnvLine(raw); ok {
+        line.Kind = LineEnv
+        line.Env = env
+        return line
+    }
+
+    fields := strings.Fields(trim)
+
+    job, err := parseJob(raw, fields, format)
+    if err != nil {
+        line.Kind = LineInvalid
+        line.Err = err
+        return line
+    }
+    line.Kind = LineJob
+    line.Job = job
+    return line
+}
+
+func parseJob(raw string, fields []string, format Format) (*CronJob, error) {
+    job := &CronJob{Raw: raw}
+
+    scheduleFields := 5
+    if strings.HasPrefix(fields[0], "@") {
+        scheduleFields = 1
+    }
+    needed := scheduleFields + 1
+    if format == SystemFormat {
+        needed++
+    }
+    if len(fields) < needed {
+        if format == SystemFormat {
+            return nil, fmt.Errorf("expected a schedule, a user and a command")
+        }
+        return nil, fmt.Errorf("expected 5 schedule fields followed by a command")
+    }
+
+    job.Schedule = make([]string, scheduleFields)
+    copy(job.Schedule, fields[:scheduleFields])
+    if scheduleFields == 1 {
+        spec, err := ParseSchedule(job.Schedule)
+        if err != nil {
+            return nil, err
+        }
+        job.Spec = spec
+        job.Description = describeSpecial(fields[0])
+    } else {
+        if err := validateSchedule(job.Schedule, 0); err != nil {
+            return nil, err
+        }
+        job.Description = DescribeSchedule(job.Schedule)
+        job.Spec, _ = ParseSchedule(job.Schedule)
+    }
+
+    if format == SystemFormat {
+        job.User = fields[scheduleFields]
+        job.Command = restAfterFields(raw, scheduleFields+1)
+    } else {
+        job.Command = restAfterFields(raw, scheduleFields)
+    }
+    return job, nil
+}
+
+func parseDisabledJob(raw string, format Format) *CronJob {
+    text := EnableLine(raw)
+    fields := strings.Fields(text)
+    if len(fields) == 0 {
+        return nil
+    }
+    // Be stricter than for live lines so prose such as "# @-specials go
+    // here" stays a comment.
+    if strings.HasPrefix(fields[0], "@") {
+        if _, err := ParseSchedule(fields[:1]); err != nil {
+            return nil
+        }
+    }
+    job, err := parseJob(text, fields, format)
+    if err != nil {
+        return nil
+    }
+    job.Raw = raw
+    job.Disabled = true
+    return job
+}
+
+// DisableLine comments a line out.
+func DisableLine(text string) string {
+    return "# " + text
+}
+
+// EnableLine removes the first # of a commented out line along with one
+// space following it, keeping any indentation.
+func EnableLine(text string) string {
+    i := strings.Index(text, "#")
+    if i < 0 || strings.TrimSpace(text[:i]) != "" {
+        return text
+    }
+    rest := text[i+1:]
+    rest = strings.TrimPrefix(rest, " ")
+    return text[:i] + rest
+}
+
+// restAfterFields returns s without its first n whitespace separated
+// fields, keeping the spacing of the remainder intact.
+func restAfterFields(s string, n int) string {
+    s = strings.TrimSpace(s)
+    for i := 0; i < n; i++ {
+        s = strings.TrimLeft(s, " \t")
+        end := strings.IndexAny(s, " \t")
+        if end < 0 {
+            return ""
+        }
+        s = s[end:]
+    }
+    return strings.TrimLeft(s, " \t")
+}
+
+func (doc *Document) renumber() {
+    for i, line := range doc.Lines {
+        line.Number = i + 1
+        if line.Job != nil {
+            line.Job.LineNumber = line.Number
+        }
+    }
+}
+
+func (doc *Document) Bytes() []byte {
+    var buf bytes.Buffer
+    for _, line := range doc.Lines {
+        buf.WriteString(line.Text)
+        buf.WriteString(line.EOL)
+    }
+    return buf.Bytes()
+}
+
+func (doc *Document) String() string {
+    return string(doc.Bytes())
+}
+
+// Result collects the jobs of the document in file order, along with the
+// lines cron rejects.
+func (doc *Document) Result() *Result {
+    result := &Result{CronJobs: make([]CronJob, 0), Format: doc.Format}
+    env := map[string]string{}
+    var order []string
+    for _, line := range doc.Lines {
+        switch line.Kind {
+        case LineEnv:
+            // Jobs share the map until the next assignment.
+            next := make(map[string]string, len(env)+1)
+            for name, value := range env {
+                next[name] = value
+            }
+            if _, ok := env[line.Env.Name]; !ok {
+                order = append(order[:len(order):len(order)], line.Env.Name)
+            }
+            next[line.Env.Name] = line.Env.Value
+            env = next
+        case LineJob:
+            job := *line.Job
+            job.Env = env
+            job.EnvOrder = order
+            job.Description = DescribeIn(job.Schedule, job.Zone())
+            result.CronJobs = append(result.CronJobs, job)
+        case LineInvalid:
+            schedule, user, command := SplitJobLine(line.Text, doc.Format)
+            result.CronJobs = append(result.CronJobs, CronJob{
+                LineNumber: line.Number,
+                Raw:        line.Text,
+                Schedule:   schedule,
+                User:       user,
+                Command:    command,
+                Env:        env,
+                EnvOrder:   order,
+                Err:        line.Err,
+            })
+        }
+    }
+    return result
+}
+
+// LineAt returns the line with the given 1-based number.
+func (doc *Document) LineAt(number int) (*Line, error) {
+    if number < 1 || number > len(doc.Lines) {
+        return nil, fmt.Errorf("line %d out of range (1-%d)", number, len(doc.Lines))
+    }
+    return doc.Lines[number-1], nil
+}
+
+// eol returns the terminator used by the document, so new lines match.
+func (doc *Document) eol() string {
+    for _, line := range doc.Lines {
+        if line.EOL != "" {
+            return line.EOL
+        }
+    }
+    return "\n"
+}
+
+// InsertLine inserts text before the line with the given 1-based number;
+// number len(Lines)+1 appends.
+func (doc *Document) InsertLine(number int, text string) (*Line, error) {
+    if number < 1 || number > len(doc.Lines)+1 {
+        return nil, fmt.Errorf("line %d out of range (1-%d)", number, len(doc.Lines)+1)
+    }
+    eol := doc.eol()
+    // cron ignores a last line without a terminator, make sure whatever
+    // ends up before the new line has one.
+    if number > 1 && doc.Lines[number-2].EOL == "" {
+        doc.Lines[number-2].EOL = eol
+    }
+    line := parseLine(text, doc.Format)
+    line.EOL = eol
+    doc.Lines = append(doc.Lines, nil)
+    copy(doc.Lines[number:], doc.Lines[number-1:])
+    doc.Lines[number-1] = line
+    doc.renumber()
+    return line, nil
+}
+
+func (doc *Document) AppendLine(text string) *Line {
+    line, _ := doc.InsertLine(len(doc.Lines)+1, text)
+    return line
+}
+
+func (doc *Document) AppendJob(schedule []string, user string, command string) *Line {
+    return doc.AppendLine(FormatJobLine(schedule, user, command))
+}
+
+// ReplaceLine swaps the text of a line, keeping its terminator.
+func (doc *Document) ReplaceLine(number int, text string) (*Line, error) {
+    old, err := doc.LineAt(number)
+    if err != nil {
+        return nil, err
+    }
+    line := parseLine(text, doc.Format)
+    line.EOL = old.EOL
+    doc.Lines[number-1] = line
+    doc.renumber()
+    return line, nil
+}
+
+// ToggleLine comments out an enabled job or restores a disabled one.
+func (doc *Document) ToggleLine(number int) (*Line, error) {
+    line, err := doc.LineAt(number)
+    if err != nil {
+        return nil, err
+    }
+    if line.Kind != LineJob {
+        return nil, fmt.Errorf("line %d is not a job", number)
+    }
+    if line.Job.Disabled {
+        return doc.ReplaceLine(number, EnableLine(line.Text))
+    }
+    return doc.ReplaceLine(number, DisableLine(line.Text))
+}
+
+func (doc *Document) DeleteLine(number int) error {
+    if _, err := doc.LineAt(number); err != nil {
+        return err
+    }
+    doc.Lines = append(doc.Lines[:number-1], doc.Lines[number:]...)
+    doc.renumber()
+    return nil
+}
+
+// SplitJobLine separates a job line into its schedule fields (one for an
+// @-special, five otherwise), the user for the system format, and the
+// command, keeping the command's spacing. It does not validate anything.
+func SplitJobLine(text string, format Format) ([]string, string, string) {
+    fields := strings.Fields(text)
+    n := 5
+    if len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+        n = 1
+    }
+    if len(fields) < n {
+        return fields, "", ""
+    }
+    if format == SystemFormat {
+        if len(fields) == n {
+            return fields[:n], "", ""
+        }
+        return fields[:n], fields[n], restAfterFields(text, n+1)
+    }
+    return fields[:n], "", restAfterFields(text, n)
+}
+
+// FormatJobLine builds a job line, user is left out when empty.
+func FormatJobLine(schedule []string, user string, command string) string {
+    if user != "" {
+        return fmt.Sprintf("%s %s %s", strings.Join(schedule, " "), user, command)
+    }
+    return fmt.Sprintf("%s %s", strings.Join(schedule, " "), command)
+}
@@ -0,0 +1,178 @@
+package parser
+
+import (
+    "testing"
+)
+
+const sampleCrontab = "# m h dom mon dow command\n" +
+    "MAILTO=ops@example.com\n" +
+    "\n" +
+    "*/5 * * * * /usr/bin/check   --quiet\r\n" +
+    "61 * * * * /bin/broken\n" +
+    "@daily /usr/local/bin/backup\n" +
+    "  # indented comment\n" +
+    "0 9 * * MON-FRI /bin/report"
+
+func TestDocumentRoundTrip(t *testing.T) {
+    inputs := []string{
+        sampleCrontab,
+        sampleCrontab + "\n",
+        "",
+        "\n\n",
+        "\r\n",
+        "no newline at all",
+    }
+    for _, in := range inputs {
+        doc := ParseDocument([]byte(in))
+        if got := doc.String(); got != in {
+            t.Errorf("round trip changed input\n got: %q\nwant: %q", got, in)
+        }
+    }
+}
+
+func TestDocumentLineKinds(t *testing.T) {
+    doc := ParseDocument([]byte(sampleCrontab))
+    want := []LineKind{LineComment, LineEnv, LineBlank, LineJob, LineInvalid, LineJob, LineComment, LineJob}
+    if len(doc.Lines) != len(want) {
+        t.Fatalf("got %d lines, want %d", len(doc.Lines), len(want))
+    }
+    for i, line := range doc.Lines {
+        if line.Kind != want[i] {
+            t.Errorf("line %d: kind %v, want %v", i+1, line.Kind, want[i])
+        }
+        if line.Number != i+1 {
+            t.Errorf("line %d: number %d", i+1, line.Number)
+        }
+    }
+    if env := doc.Lines[1].Env; env.Name != "MAILTO" || env.Value != "ops@example.com" {
+        t.Errorf("env = %+v", env)
+    }
+    if job := doc.Lines[3].Job; job.Command != "/usr/bin/check   --quiet" || job.LineNumber != 4 {
+        t.Errorf("job = %+v", job)
+    }
+    if doc.Lines[4].Err == nil {
+        t.Errorf("expected an error for the invalid line")
+    }
+    // The invalid line is listed too, so that it can be fixed.
+    result := doc.Result()
+    if n := len(result.CronJobs); n != 4 {
+        t.Errorf("got %d jobs, want 4", n)
+    }
+    if n := result.Invalid(); n != 1 {
+        t.Errorf("got %d invalid lines, want 1", n)
+    }
+}
+
+func TestDocumentUnknownSpecial(t *testing.T) {
+    doc := ParseDocument([]byte("@bogus /bin/true\n@Daily /bin/true\n# @bogus /bin/true\n"))
+    want := []LineKind{LineInvalid, LineJob, LineComment}
+    for i, line := range doc.Lines {
+        if line.Kind != want[i] {
+            t.Errorf("line %d: kind %v, want %v", i+1, line.Kind, want[i])
+        }
+    }
+    if err := doc.Lines[0].Err; err == nil || err.Error() != "unknown special schedule '@bogus'" {
+        t.Errorf("error %v", err)
+    }
+}
+
+func TestDocumentEditing(t *testing.T) {
+    doc := ParseDocument([]byte(sampleCrontab))
+
+    doc.AppendJob([]string{"0", "0", "*", "*", "*"}, "", "/bin/true")
+    want := sampleCrontab + "\n0 0 * * * /bin/true\n"
+    if got := doc.String(); got != want {
+        t.Fatalf("append:\n got: %q\nwant: %q", got, want)
+    }
+
+    if _, err := doc.ReplaceLine(4, "*/10 * * * * /usr/bin/check"); err != nil {
+        t.Fatal(err)
+    }
+    if doc.Lines[3].EOL != "\r\n" {
+        t.Errorf("replace lost the line terminator")
+    }
+
+    if err := doc.DeleteLine(5); err != nil {
+        t.Fatal(err)
+    }
+    if doc.Lines[4].Job == nil || doc.Lines[4].Job.LineNumber != 5 {
+        t.Errorf("lines were not renumbered after delete")
+    }
+
+    if err := doc.DeleteLine(100); err == nil {
+        t.Errorf("expected out of range error")
+    }
+}
+
+func TestDocumentDisabledJobs(t *testing.T) {
+    in := "# 0 4 * * * /usr/bin/cleanup\n" +
+        "#@daily /bin/backup\n" +
+        "# 5 things to do\n" +
+        "# @weekly-ish jobs go below\n" +
+        "## 0 * * * * /bin/double\n"
+    doc := ParseDocument([]byte(in))
+
+    want := []LineKind{LineJob, LineJob, LineComment, LineComment, LineComment}
+    for i, line := range doc.Lines {
+        if line.Kind != want[i] {
+            t.Errorf("line %d: kind %v, want %v", i+1, line.Kind, want[i])
+        }
+    }
+    job := doc.Lines[0].Job
+    if !job.Disabled || job.Command != "/usr/bin/cleanup" || job.Raw != "# 0 4 * * * /usr/bin/cleanup" {
+        t.Errorf("job = %+v", job)
+    }
+
+    line, err := doc.ToggleLine(1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if line.Text != "0 4 * * * /usr/bin/cleanup" || line.Job.Disabled {
+        t.Errorf("enable gave %q", line.Text)
+    }
+    if _, err := doc.ToggleLine(1); err != nil {
+        t.Fatal(err)
+    }
+    if got := doc.String(); got != in {
+        t.Errorf("toggling twice changed the document:\n%q", got)
+    }
+    if _, err := doc.ToggleLine(3); err == nil {
+        t.Errorf("expected an error toggling a comment")
+    }
+}
+
+func TestDocumentSystemFormat(t *testing.T) {
+    in := "SHELL=/bin/sh\n" +
+        "17 *\t* * *\troot    cd / && run-parts --report /etc/cron.hourly\n" +
+        "@reboot www-data /usr/bin/warmup\n" +
+        "0 0 * * * /bin/no-user\n"
+
+    doc := ParseDocumentFormat([]byte(in), SystemFormat)
+    jobs := doc.Result().CronJobs
+    if len(jobs) != 3 {
+        t.Fatalf("got %d jobs, want 3", len(jobs))
+    }
+    if jobs[0].User != "root" || jobs[0].Command != "cd / && run-parts --report /etc/cron.hourly" {
+        t.Errorf("job 1 = %+v", jobs[0])
+    }
+    if jobs[1].User != "www-data" || jobs[1].Command != "/usr/bin/warmup" {
+        t.Errorf("job 2 = %+v", jobs[1])
+    }
+    if doc.Lines[3].Kind != LineInvalid {
+        t.Errorf("a system line without user should be invalid, got %v", doc.Lines[3].Kind)
+    }
+    if jobs[2].Err == nil || jobs[2].LineNumber != 4 || jobs[2].Raw != "0 0 * * * /bin/no-user" {
+        t.Errorf("invalid line = %+v", jobs[2])
+    }
+
+    // The same @reboot line in a user crontab has no user column.
+    user := ParseDocument([]byte("@reboot www-data /usr/bin/warmup\n")).Result().CronJobs[0]
+    if user.User != "" || user.Command != "www-data /usr/bin/warmup" {
+        t.Errorf("user format job = %+v", user)
+    }
+
+    doc.AppendJob([]string{"@daily"}, "root", "/bin/true")
+    if last := doc.Lines[len(doc.Lines)-1]; last.Job == nil || last.Job.User != "root" {
+        t.Errorf("appended line did not parse in system format: %+v", last)
+    }
+}
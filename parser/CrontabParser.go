@@ -1,171 +1,106 @@
 package parser
 
 import (
-    "os"
     "fmt"
     "io"
     //"log"
-    "bufio"
     "strings"
     "strconv"
+    "time"
 )
 
 type CronJob struct {
+    LineNumber  int
     Raw         string
     Schedule    []string
     User        string
     Command     string
     Description string
+    Spec        *CronSchedule
+    Disabled    bool
+    Source      string
+    // Via is the line that starts a job listed without one of its own, as
+    // the scripts of a run-parts directory are, or ViaAnacron.
+    Via         string
+    // Env holds the crontab's assignments in effect for the job, without
+    // cron's defaults.
+    Env         map[string]string
+    // EnvOrder lists the names in Env in the order cron first sets them.
+    EnvOrder    []string
+    // Err is set for a line cron rejects, listed so that it can be fixed.
+    // Schedule, User and Command then hold what SplitJobLine makes of it.
+    Err         error
 }
 
+// ViaAnacron is the Via of jobs anacron starts, which have no schedule.
+const ViaAnacron = "anacron"
+
 type Result struct {
     CronJobs []CronJob
+    Format   Format
+    // Aggregated results merge several sources, Draw then adds the user
+    // and origin of every job.
+    Aggregated bool
+}
+
+type fieldRange struct {
+    name  string
+    min   int
+    max   int
+    names map[string]int
+}
+
+var cronRanges = []fieldRange{
+    {"minute", 0, 59, nil},
+    {"hour", 0, 23, nil},
+    {"day of month", 1,31, nil},
+    {"month", 1, 12, monthValues},
+    {"day of week", 0, 7, weekdayValues},
+}
+
+var monthValues = map[string]int{
+    "JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+    "JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
 }
 
-var cronRanges = []struct {
-    name string
-    min int
-    max int
-}{
-    {"minute", 0, 59},
-    {"hour", 0, 23},
-    {"day of month", 1,31},
-    {"month", 1, 12},
-    {"day of week", 0, 7},
+var weekdayValues = map[string]int{
+    "SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
 }
 
 func ParseCrontab(path string) (*Result, error) {
-    file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	const maxCapacity = 1024*1024
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, maxCapacity)
-
-	//var jobs []CronJob
-	jobs := make([]CronJob, 0)
-	lineNo := 0
-
-	for scanner.Scan() {
-	    lineNo++
-	    raw := scanner.Text()
-	    trim := strings.TrimSpace(raw)
-	    if trim == "" || strings.HasPrefix(trim, "#") {
-	        continue
-	    }
-	    firstTok := strings.Fields(trim)
-	    if len(firstTok) > 0 && strings.Contains(firstTok[0], "=") && !strings.HasPrefix(firstTok[0], "\"") {
-	        continue
-	    }
-
-	    fields := strings.Fields(trim)
-	    if len(fields) == 0 {
-	        continue
-	    }
-	    job := CronJob { Raw: raw }
-
-	    if strings.HasPrefix(fields[0], "@") {
-	        job.Schedule = []string{fields[0]}
-	        job.Description = describeSpecial(fields[0])
-	        if len(fields) < 2 {
-	            job.Command = ""
-	            jobs = append(jobs, job)
-	            continue
-	        }
-	        if len(fields) >= 3 {
-	            job.User = fields[1]
-	            job.Command = strings.Join(fields[2:], " ")
-	        } else {
-	            job.Command = strings.Join(fields[1:], " ")
-	        }
-	        jobs = append(jobs, job)
-	        continue
-	    }
-
-	    if len(fields) < 6 {
-	        continue
-	    }
-
-	    if err := validateSchedule(fields[:5], lineNo); err != nil {
-	        fmt.Fprintf(os.Stderr, "Warning: invalid schedule on line %d", lineNo)
-	        continue
-	    }
-
-	    job.Schedule = make([]string, 5)
-	    copy(job.Schedule, fields[:5])
-	    job.Description = DescribeSchedule(job.Schedule)
-	    //if len(fields) >= 7 {
-	    //    job.User = fields[5]
-	    //    job.Command = strings.Join(fields[6:], " ")
-	    //} else {
-	    //    job.User = ""
-	        job.Command = strings.Join(fields[5:], " ")
-	    //}
-	    jobs = append(jobs, job)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
-	}
-
-	result := &Result{}
-	result.CronJobs = make([]CronJob, len(jobs))
-	copy(result.CronJobs, jobs)
-
-	return result, nil
+    doc, err := LoadDocument(path)
+    if err != nil {
+        return nil, err
+    }
+    return doc.Result(), nil
 }
 
 func validateSchedule(fields []string, line int) error {
     for i, field := range fields {
-        if err := validateField(field, cronRanges[i].min, cronRanges[i].max); err != nil {
+        if err := ValidateScheduleField(i, field); err != nil {
             return fmt.Errorf("%s field '%s' invalid: %v", cronRanges[i].name, field, err)
         }
     }
     return nil
 }
 
-func validateField(field string, min, max int) error {
-    if field == "*" {
-        return nil
-    }
-    if strings.HasPrefix(field, "*/") {
-        step, err := strconv.Atoi(field[2:])
-        if err != nil || step <= 0 {
-            return fmt.Errorf("invalid step value in '%s'", field)
-        }
-        return nil
+// ValidateScheduleField checks a single schedule field, index 0 being the
+// minute and 4 the day of week.
+func ValidateScheduleField(index int, field string) error {
+    if index < 0 || index >= len(cronRanges) {
+        return fmt.Errorf("no schedule field %d", index+1)
     }
+    _, err := parseField(field, cronRanges[index])
+    return err
+}
 
-    parts := strings.Split(field, ",")
-    for _, p := range parts {
-        if strings.Contains(p, "-") {
-            bounds := strings.Split(p, "-")
-            if len(bounds) != 2 {
-                return fmt.Errorf("invalid range '%s'", p)
-            }
-            start, err1 := strconv.Atoi(bounds[0])
-            end, err2 := strconv.Atoi(bounds[1])
-            if err1 != nil || err2 != nil {
-                return fmt.Errorf("invalid range numbers in '%s'", p)
-            }
-            if start < min || end > max || start > end {
-                return fmt.Errorf("range out of bounds (%d-%d) in '%s'", min, max, p)
-            }
-        } else {
-            val, err := strconv.Atoi(p)
-            if err != nil {
-                return fmt.Errorf("invalid integer '%s'", p)
-            }
-            if val < min || val > max {
-                return fmt.Errorf("value %d out of range (%d-%d)", val, min, max)
-            }
-        }
+// Describe explains a schedule given either as the five fields or as a
+// single @-special.
+func Describe(fields []string) string {
+    if len(fields) == 1 {
+        return describeSpecial(strings.ToLower(fields[0]))
     }
-    return nil
+    return DescribeSchedule(fields)
 }
 
 func describeSpecial(token string) string {
@@ -194,24 +129,23 @@ func DescribeSchedule(fields []string) string {
     minute, hour, dom, mon, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
     //desc := fmt.Sprintf("At minute %s, hour %s, day-of-month %s, month %s, day-of-week %s",
     //    minute, hour, dom, mon, dow)
-    if strings.HasPrefix(minute, "*/") && hour == "*" && dom == "*" && mon == "*" && dow == "*" {
+    if strings.HasPrefix(minute, "*/") && isNumber(minute[2:]) && hour == "*" && dom == "*" && mon == "*" && dow == "*" {
         return fmt.Sprintf("Every %s minutes", minute[2:])
     }
-    if hour == "*" && dom == "*" && mon == "*" && dow == "*" && minute != "*" {
-        return fmt.Sprintf("Every hour at :%s", minute)
+    if hour == "*" && dom == "*" && mon == "*" && dow == "*" && isNumber(minute) {
+        return fmt.Sprintf("Every hour at :%02s", minute)
+    }
+    if dow != "*" && isWeekdays(dow) && minute == "0" && hour == "0" && dom == "*" && mon == "*" {
+        return "Every weekday at midnight"
     }
-    if minute == "0" && hour == "0" && dom == "*" {
+    if minute == "0" && hour == "0" && dom == "*" && mon == "*" && dow == "*" {
         return "Every day at midnight"
     }
-    if dom == "*" && mon == "*" && dow == "*" && minute != "0" && hour != "*" {
+    if dom == "*" && mon == "*" && dow == "*" && isNumber(minute) && isNumber(hour) {
         return fmt.Sprintf("Every day at %s", formatTime(hour, minute))
     }
 
-    if dom == "1-5" && minute == "0" && hour == "0" && dom == "*" && mon == "*" {
-        return "Every weekday at midnight"
-    }
-
-    if dow != "*" && dom == "*" && mon == "*" {
+    if dow != "*" && dom == "*" && mon == "*" && isNumber(minute) && isNumber(hour) {
         return fmt.Sprintf("Every %s at %s", describeWeekdayList(dow), formatTime(hour, minute))
     }
 
@@ -221,15 +155,15 @@ func DescribeSchedule(fields []string) string {
     if t != "" {parts = append(parts, t)}
 
     if dom != "*" {
-        parts = append(parts, " on the " + describeOrdinalList(dom))
+        parts = append(parts, "on " + describeOrdinalList(dom))
     }
 
     if mon != "*" {
-        parts = append(parts, " in " + describeMonthList(mon))
+        parts = append(parts, "in " + describeMonthList(mon))
     }
     
     if dow != "*" {
-        parts = append(parts, " on " + describeWeekdayList(dow))
+        parts = append(parts, "on " + describeWeekdayList(dow))
     }
 
     if len(parts) == 0 {
@@ -239,7 +173,13 @@ func DescribeSchedule(fields []string) string {
     return strings.Join(parts, " ")
 }
 
-func (result *Result) Draw(writer io.Writer) error { 
+func (result *Result) Draw(writer io.Writer) error {
+    return result.DrawAt(writer, time.Now())
+}
+
+// DrawAt draws the list with the next runs after now, shown in the
+// location of now.
+func (result *Result) DrawAt(writer io.Writer, now time.Time) error {
     if result == nil || writer == nil {
         return nil
     }
@@ -249,13 +189,113 @@ func (result *Result) Draw(writer io.Writer) error {
     }
 
     for _, item := range result.CronJobs {
-        fmt.Fprintf(writer, "%-20s %s\n", strings.Join(item.Schedule, " "), item.Command)
+        next := FormatRunTime(item.NextRun(now))
+        if item.Via == ViaAnacron {
+            next = "via anacron"
+        }
+        if item.Disabled {
+            next = "# disabled"
+        }
+        if item.Err != nil {
+            next = "! invalid"
+        }
+        row := fmt.Sprintf("%-20s %-16s ", strings.Join(item.Schedule, " "), next)
+        if result.Format == SystemFormat || result.Aggregated {
+            row += fmt.Sprintf("%-10s ", item.User)
+        }
+        if result.Aggregated {
+            row += fmt.Sprintf("%-24s ", truncateLeft(fmt.Sprintf("%s:%d", item.Source, item.LineNumber), 24))
+        }
+        row += item.Command
+        if item.Disabled {
+            // Bold black renders as dark grey on most terminals.
+            row = "\033[30;1m" + row + "\033[0m"
+        }
+        if item.Err != nil {
+            row = "\033[31m" + row + "\033[0m"
+        }
+        fmt.Fprintln(writer, row)
     }
     return nil
 }
 
+// Filter returns the jobs coming from the given source, all of them when
+// source is empty.
+func (result *Result) Filter(source string) *Result {
+    if source == "" {
+        return result
+    }
+    filtered := &Result{CronJobs: make([]CronJob, 0), Format: result.Format, Aggregated: result.Aggregated}
+    for _, job := range result.CronJobs {
+        if job.Source == source {
+            filtered.CronJobs = append(filtered.CronJobs, job)
+        }
+    }
+    return filtered
+}
+
+// Invalid counts the lines cron rejects.
+func (result *Result) Invalid() int {
+    n := 0
+    for _, job := range result.CronJobs {
+        if job.Err != nil {
+            n++
+        }
+    }
+    return n
+}
+
+// Sources lists the distinct job origins in order of appearance.
+func (result *Result) Sources() []string {
+    seen := map[string]bool{}
+    sources := []string{}
+    for _, job := range result.CronJobs {
+        if !seen[job.Source] {
+            seen[job.Source] = true
+            sources = append(sources, job.Source)
+        }
+    }
+    return sources
+}
+
+func truncateLeft(s string, width int) string {
+    if len(s) <= width {
+        return s
+    }
+    return "…" + s[len(s)-width+1:]
+}
+
+// NextRun returns the first time the job fires after the given time, or
+// the zero time if it never does. The schedule is evaluated in the job's
+// Location, the result is in the location of after.
+func (job *CronJob) NextRun(after time.Time) time.Time {
+    return job.Spec.Next(after.In(job.Location())).In(after.Location())
+}
+
+// NextRuns returns up to n upcoming fire times, like NextRun.
+func (job *CronJob) NextRuns(after time.Time, n int) []time.Time {
+    if job.Spec == nil {
+        return nil
+    }
+    runs := job.Spec.NextN(after.In(job.Location()), n)
+    for i := range runs {
+        runs[i] = runs[i].In(after.Location())
+    }
+    return runs
+}
+
+func FormatRunTime(t time.Time) string {
+    if t.IsZero() {
+        return "-"
+    }
+    return t.Format("2006-01-02 15:04")
+}
+
 func friendlyList(field string) string {
-    parts := strings.Split(field, ",")
+    return joinList(strings.Split(field, ","))
+}
+
+func joinList(parts []string) string {
     if len(parts) == 1 {
         return parts[0]
     }
@@ -268,47 +308,50 @@ func friendlyList(field string) string {
 }
 
 func describePart(label, field string) string {
-    if field == "*" {
-        if label == "minute" {
-            return "every minute"
-        }
-        if label == "hour" {
-            return " every hour"
-        }
-        return ""
+    return describeList(field, label, func(v string) string {
+        return label + " " + v
+    })
+}
+
+// describeList describes every comma separated element of a field. unit
+// is what a step counts ("minute", "day", ...) and render turns a single
+// value into text.
+func describeList(field, unit string, render func(string) string) string {
+    var items []string
+    for _, p := range strings.Split(field, ",") {
+        items = append(items, describeElement(p, unit, render))
     }
+    return joinList(items)
+}
 
-    // Step: */5 or 1-10/2
-    if strings.Contains(field, "/") {
-        parts := strings.Split(field, "/")
-        base, step := parts[0], parts[1]
+// describeElement handles a single value, a range, and either of those
+// (or *) followed by a step: 5, 1-10, */5, 1-10/2, 5/15.
+func describeElement(part, unit string, render func(string) string) string {
+    base, step, stepped := strings.Cut(part, "/")
+    from, to, isRange := strings.Cut(base, "-")
 
+    if !stepped {
         if base == "*" {
-            return fmt.Sprintf(" every %s %ss", step, label)
+            return "every " + unit
         }
-        return fmt.Sprintf(" every %s %ss between %s", step, label, describeRange(base))
-    }
-
-    // Range: 1-5
-    if strings.Contains(field, "-") {
-        return fmt.Sprintf(" at each %s from %s", label, describeRange(field))
+        if isRange {
+            return fmt.Sprintf("%s through %s", render(from), render(to))
+        }
+        return render(base)
     }
 
-    // List: 5,25,45
-    if strings.Contains(field, ",") {
-        return fmt.Sprintf(" at %s %ss", friendlyList(field), label)
+    desc := fmt.Sprintf("every %s %ss", step, unit)
+    if step == "1" {
+        desc = "every " + unit
     }
-
-    // Single value
-    return fmt.Sprintf(" at %s %s", field, label)
-}
-
-func describeRange(r string) string {
-    parts := strings.Split(r, "-")
-    if len(parts) != 2 {
-        return r
+    switch {
+    case base == "*":
+    case isRange:
+        desc += fmt.Sprintf(" from %s through %s", render(from), render(to))
+    default:
+        desc += " starting at " + render(base)
     }
-    return fmt.Sprintf("%s to %s", parts[0], parts[1])
+    return desc
 }
 
 func describeTime(hour, minute string) string {
@@ -316,20 +359,33 @@ func describeTime(hour, minute string) string {
         return ""
     }
 
-    if hour == "*" && minute != "*" {
-        return fmt.Sprintf("every hour at :%s", minute)
+    // both fixed → HH:MM
+    if isNumber(hour) && isNumber(minute) {
+        return "at " + formatTime(hour, minute)
+    }
+
+    if hour == "*" && isNumber(minute) {
+        return fmt.Sprintf("every hour at :%02s", minute)
     }
 
+    hours := describeList(hour, "hour", formatHour)
     if hour != "*" && minute == "*" {
-        return fmt.Sprintf("every minute during %s", formatHour(hour))
+        return fmt.Sprintf("every minute during %s", hours)
     }
 
-    // both fixed → HH:MM
-    if hour != "*" && minute != "*" {
-        return "at " + formatTime(hour, minute)
+    minutes := describePart("minute", minute)
+    if hour == "*" {
+        return minutes
+    }
+    if isNumber(minute) {
+        return fmt.Sprintf("at minute %s during %s", minute, hours)
     }
+    return fmt.Sprintf("%s during %s", minutes, hours)
+}
 
-    return ""
+func isNumber(s string) bool {
+    _, err := strconv.Atoi(s)
+    return err == nil
 }
 
 func formatTime(hour, minute string) string {
@@ -367,44 +423,42 @@ var weekdayNames = map[string]string{
 }
 
 func describeMonthList(field string) string {
-    parts := strings.Split(field, ",")
-
-    var names []string
-    for _, p := range parts {
-        if name, ok := monthNames[p]; ok {
-            names = append(names, name)
-        } else {
-            names = append(names, p)
-        }
-    }
-
-    return friendlyList(strings.Join(names, ","))
+    return describeNamedList(field, cronRanges[3], monthNames, "month")
 }
 
 func describeWeekdayList(field string) string {
-    parts := strings.Split(field, ",")
-
-    var names []string
-    for _, p := range parts {
-        if name, ok := weekdayNames[p]; ok {
-            names = append(names, name)
-        } else {
-            names = append(names, p)
-        }
-    }
-
-    return friendlyList(strings.Join(names, ","))
+    return describeNamedList(field, cronRanges[4], weekdayNames, "day")
 }
 
-func describeOrdinalList(field string) string {
-    parts := strings.Split(field, ",")
+// describeNamedList renders months or weekdays, written either as numbers
+// or as names, with their full names.
+func describeNamedList(field string, r fieldRange, names map[string]string, unit string) string {
+    return describeList(field, unit, func(v string) string {
+        return lookupName(v, r, names)
+    })
+}
 
-    var list []string
-    for _, p := range parts {
-        list = append(list, ordinal(p))
+func lookupName(token string, r fieldRange, names map[string]string) string {
+    v, err := parseValue(token, r)
+    if err != nil {
+        return token
     }
+    if name, ok := names[strconv.Itoa(v)]; ok {
+        return name
+    }
+    return token
+}
 
-    return friendlyList(strings.Join(list, ","))
+// isWeekdays reports whether a day of week field means Monday to Friday.
+func isWeekdays(field string) bool {
+    bits, err := parseField(field, cronRanges[4])
+    return err == nil && bits == bitRange(1, 5, 1)
+}
+
+func describeOrdinalList(field string) string {
+    return describeList(field, "day", func(v string) string {
+        return "the " + ordinal(v)
+    })
 }
 
 func ordinal(s string) string {
@@ -0,0 +1,116 @@
+package parser
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Defaults cron starts jobs with, before the crontab's own assignments.
+// MAILTO defaults to the owner of the crontab.
+var DefaultEnv = map[string]string{
+    "PATH":  "/usr/bin:/bin",
+    "SHELL": "/bin/sh",
+}
+
+// ParseEnvLine reads a NAME=value assignment the way cron's load_env does:
+// spaces are allowed around =, the name may be quoted, and a value wrapped
+// in matching single or double quotes loses them. There is no escaping,
+// and trailing blanks of an unquoted value are dropped.
+func ParseEnvLine(text string) (*EnvVar, bool) {
+    s := strings.TrimLeft(text, " \t")
+    var name string
+    if s != "" && (s[0] == '"' || s[0] == '\'') {
+        end := strings.IndexByte(s[1:], s[0])
+        if end < 0 {
+            return nil, false
+        }
+        name, s = s[1:end+1], s[end+2:]
+    } else {
+        end := strings.IndexAny(s, "= \t")
+        if end < 0 {
+            return nil, false
+        }
+        name, s = s[:end], s[end:]
+    }
+    s = strings.TrimLeft(s, " \t")
+    if name == "" || !strings.HasPrefix(s, "=") {
+        return nil, false
+    }
+    value := strings.TrimRight(strings.TrimLeft(s[1:], " \t"), " \t")
+    if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+        value = value[1 : len(value)-1]
+    }
+    return &EnvVar{Name: name, Value: value}, true
+}
+
+// FormatEnvLine writes an assignment that ParseEnvLine reads back as
+// name and value, quoting the value only where cron would otherwise
+// change it.
+func FormatEnvLine(name string, value string) (string, error) {
+    if name == "" {
+        return "", fmt.Errorf("empty variable name")
+    }
+    if strings.ContainsAny(name, "= \t\r\n") || name[0] == '"' || name[0] == '\'' || name[0] == '#' {
+        return "", fmt.Errorf("invalid variable name %q", name)
+    }
+    if strings.ContainsAny(value, "\r\n") {
+        return "", fmt.Errorf("the value of %s must be a single line", name)
+    }
+    quote := value != strings.TrimSpace(value)
+    if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+        quote = true
+    }
+    if quote {
+        value = "\"" + value + "\""
+    }
+    return name + "=" + value, nil
+}
+
+// Environment returns the variables in effect for a job on the given
+// line: the assignments above it, the last one of a name winning.
+func (doc *Document) Environment(number int) map[string]string {
+    env := map[string]string{}
+    for _, line := range doc.Lines {
+        if line.Number >= number {
+            break
+        }
+        if line.Kind == LineEnv {
+            env[line.Env.Name] = line.Env.Value
+        }
+    }
+    return env
+}
+
+// Variables returns the environment lines in file order.
+func (doc *Document) Variables() []*Line {
+    var lines []*Line
+    for _, line := range doc.Lines {
+        if line.Kind == LineEnv {
+            lines = append(lines, line)
+        }
+    }
+    return lines
+}
+
+// InsertEnv adds an assignment so that it applies to every job: below
+// the assignments above the first job, or right above that job when there
+// are none.
+func (doc *Document) InsertEnv(name string, value string) (*Line, error) {
+    text, err := FormatEnvLine(name, value)
+    if err != nil {
+        return nil, err
+    }
+    number := len(doc.Lines) + 1
+    for _, line := range doc.Lines {
+        if line.Kind == LineJob || line.Kind == LineInvalid {
+            number = line.Number
+            break
+        }
+    }
+    for _, line := range doc.Lines[:number-1] {
+        if line.Kind == LineEnv {
+            number = line.Number + 1
+        }
+    }
+    return doc.InsertLine(number, text)
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+    "testing"
+)
+
+func TestParseEnvLine(t *testing.T) {
+    tests := []struct {
+        in          string
+        name, value string
+        ok          bool
+    }{
+        {"MAILTO=ops@example.com", "MAILTO", "ops@example.com", true},
+        {"  PATH = /usr/bin:/bin  ", "PATH", "/usr/bin:/bin", true},
+        {"MAILTO=\"\"", "MAILTO", "", true},
+        {"MAILTO=", "MAILTO", "", true},
+        {"GREETING='hello world '", "GREETING", "hello world ", true},
+        {"Q=\"a\"b\"", "Q", "a\"b", true},
+        {"HALF=\"open", "HALF", "\"open", true},
+        {"\"SPACED NAME\"=x", "SPACED NAME", "x", true},
+        {"A=b=c", "A", "b=c", true},
+        {"*/5 * * * * FOO=1 /bin/true", "", "", false},
+        {"0 1 * * * /bin/true", "", "", false},
+        {"=value", "", "", false},
+        {"\"unterminated=x", "", "", false},
+    }
+    for _, tt := range tests {
+        env, ok := ParseEnvLine(tt.in)
+        if ok != tt.ok {
+            t.Errorf("%q: ok = %v, want %v", tt.in, ok, tt.ok)
+            continue
+        }
+        if ok && (env.Name != tt.name || env.Value != tt.value) {
+            t.Errorf("%q: got %q=%q, want %q=%q", tt.in, env.Name, env.Value, tt.name, tt.value)
+        }
+    }
+}
+
+func TestFormatEnvLineRoundTrip(t *testing.T) {
+    for _, value := range []string{"", "plain", " padded ", "\"quoted\"", "'single'", "a\"b", "x=y"} {
+        text, err := FormatEnvLine("NAME", value)
+        if err != nil {
+            t.Fatalf("%q: %v", value, err)
+        }
+        env, ok := ParseEnvLine(text)
+        if !ok || env.Name != "NAME" || env.Value != value {
+            t.Errorf("%q written as %q reads back as %+v", value, text, env)
+        }
+    }
+    for _, name := range []string{"", "A B", "A=B", "#A", "\"A"} {
+        if _, err := FormatEnvLine(name, "x"); err == nil {
+            t.Errorf("accepted name %q", name)
+        }
+    }
+    if _, err := FormatEnvLine("A", "x\ny"); err == nil {
+        t.Errorf("accepted a value with a newline")
+    }
+}
+
+func TestDocumentEnvironment(t *testing.T) {
+    in := "# header\nMAILTO=ops\n0 1 * * * /bin/one\nPATH=/opt/bin\nMAILTO=\"\"\n0 2 * * * /bin/two\n"
+    doc := ParseDocument([]byte(in))
+
+    jobs := doc.Result().CronJobs
+    if env := jobs[0].Env; len(env) != 1 || env["MAILTO"] != "ops" {
+        t.Errorf("job 1 env = %v", env)
+    }
+    if env := jobs[1].Env; len(env) != 2 || env["MAILTO"] != "" || env["PATH"] != "/opt/bin" {
+        t.Errorf("job 2 env = %v", env)
+    }
+    if env := doc.Environment(3); env["MAILTO"] != "ops" || env["PATH"] != "" {
+        t.Errorf("environment at line 3 = %v", env)
+    }
+
+    if _, err := doc.InsertEnv("SHELL", "/bin/bash"); err != nil {
+        t.Fatal(err)
+    }
+    want := "# header\nMAILTO=ops\nSHELL=/bin/bash\n0 1 * * * /bin/one\nPATH=/opt/bin\nMAILTO=\"\"\n0 2 * * * /bin/two\n"
+    if got := doc.String(); got != want {
+        t.Errorf("insert:\n got: %q\nwant: %q", got, want)
+    }
+
+    doc = ParseDocument([]byte("# header\n0 1 * * * /bin/one\n"))
+    if _, err := doc.InsertEnv("MAILTO", ""); err != nil {
+        t.Fatal(err)
+    }
+    if got := doc.String(); got != "# header\nMAILTO=\n0 1 * * * /bin/one\n" {
+        t.Errorf("insert without variables: %q", got)
+    }
+}
@@ -0,0 +1,151 @@
+package parser
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// ExportedJob is the machine readable form of a job written by WriteJSON
+// and WriteYAML.
+type ExportedJob struct {
+    Schedule    string          `json:"schedule"`
+    Special     string          `json:"special,omitempty"`
+    Fields      *ExportedFields `json:"fields,omitempty"`
+    User        string          `json:"user,omitempty"`
+    Command     string          `json:"command"`
+    Description string          `json:"description"`
+    // Timezone is the CRON_TZ the schedule is evaluated in, if any.
+    Timezone    string          `json:"timezone,omitempty"`
+    Enabled     bool            `json:"enabled"`
+    Source      string          `json:"source,omitempty"`
+    Line        int             `json:"line"`
+    NextRuns    []string        `json:"next_runs"`
+}
+
+// ExportedFields are the five schedule fields, an @-special expanded.
+type ExportedFields struct {
+    Minute     string `json:"minute"`
+    Hour       string `json:"hour"`
+    DayOfMonth string `json:"day_of_month"`
+    Month      string `json:"month"`
+    DayOfWeek  string `json:"day_of_week"`
+}
+
+type exportDocument struct {
+    Jobs []ExportedJob `json:"jobs"`
+}
+
+// Export converts the jobs, with up to runs next fire times after now in
+// RFC 3339 format. Invalid lines are left out.
+func (result *Result) Export(now time.Time, runs int) []ExportedJob {
+    jobs := make([]ExportedJob, 0, len(result.CronJobs))
+    for _, job := range result.CronJobs {
+        if job.Err != nil {
+            continue
+        }
+        exported := ExportedJob{
+            Schedule:    strings.Join(job.Schedule, " "),
+            User:        job.User,
+            Command:     job.Command,
+            Description: job.Description,
+            Timezone:    job.Zone(),
+            Enabled:     !job.Disabled,
+            Source:      job.Source,
+            Line:        job.LineNumber,
+            NextRuns:    []string{},
+        }
+        fields := job.Schedule
+        if len(fields) == 1 {
+            exported.Special = strings.ToLower(fields[0])
+            fields = specialSchedules[exported.Special]
+        }
+        if len(fields) == 5 {
+            exported.Fields = &ExportedFields{fields[0], fields[1], fields[2], fields[3], fields[4]}
+        }
+        for _, t := range job.NextRuns(now, runs) {
+            exported.NextRuns = append(exported.NextRuns, t.Format(time.RFC3339))
+        }
+        jobs = append(jobs, exported)
+    }
+    return jobs
+}
+
+// WriteJSON writes the jobs as {"jobs": [...]}.
+func (result *Result) WriteJSON(writer io.Writer, now time.Time, runs int) error {
+    encoder := json.NewEncoder(writer)
+    encoder.SetIndent("", "  ")
+    encoder.SetEscapeHTML(false)
+    return encoder.Encode(exportDocument{result.Export(now, runs)})
+}
+
+// WriteYAML writes the same document as WriteJSON in YAML. Strings are
+// always double quoted, so no value can be mistaken for another type.
+func (result *Result) WriteYAML(writer io.Writer, now time.Time, runs int) error {
+    var out strings.Builder
+    jobs := result.Export(now, runs)
+    if len(jobs) == 0 {
+        out.WriteString("jobs: []\n")
+    } else {
+        out.WriteString("jobs:\n")
+    }
+    for _, job := range jobs {
+        item := "  - "
+        key := func(name string, value string) {
+            if value == "" {
+                fmt.Fprintf(&out, "%s%s:\n", item, name)
+            } else {
+                fmt.Fprintf(&out, "%s%s: %s\n", item, name, value)
+            }
+            item = "    "
+        }
+        key("schedule", yamlString(job.Schedule))
+        if job.Special != "" {
+            key("special", yamlString(job.Special))
+        }
+        if job.Fields != nil {
+            key("fields", "")
+            for _, field := range [][2]string{
+                {"minute", job.Fields.Minute},
+                {"hour", job.Fields.Hour},
+                {"day_of_month", job.Fields.DayOfMonth},
+                {"month", job.Fields.Month},
+                {"day_of_week", job.Fields.DayOfWeek},
+            } {
+                fmt.Fprintf(&out, "      %s: %s\n", field[0], yamlString(field[1]))
+            }
+        }
+        if job.User != "" {
+            key("user", yamlString(job.User))
+        }
+        key("command", yamlString(job.Command))
+        key("description", yamlString(job.Description))
+        if job.Timezone != "" {
+            key("timezone", yamlString(job.Timezone))
+        }
+        key("enabled", strconv.FormatBool(job.Enabled))
+        if job.Source != "" {
+            key("source", yamlString(job.Source))
+        }
+        key("line", strconv.Itoa(job.Line))
+        if len(job.NextRuns) == 0 {
+            key("next_runs", "[]")
+            continue
+        }
+        key("next_runs", "")
+        for _, run := range job.NextRuns {
+            fmt.Fprintf(&out, "      - %s\n", yamlString(run))
+        }
+    }
+    _, err := io.WriteString(writer, out.String())
+    return err
+}
+
+// yamlString quotes s as a YAML double quoted scalar, whose escapes are a
+// superset of Go's.
+func yamlString(s string) string {
+    return strconv.Quote(s)
+}
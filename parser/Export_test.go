@@ -0,0 +1,80 @@
+package parser
+
+import (
+    "bytes"
+    "encoding/json"
+    "testing"
+    "time"
+)
+
+func TestExport(t *testing.T) {
+    doc := ParseDocumentFormat([]byte("MAILTO=ops\n0 9 * * MON-FRI alice /bin/report \"weekly\"\n# @daily root /bin/cleanup\n"), SystemFormat)
+    result := doc.Result()
+    now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+
+    var buf bytes.Buffer
+    if err := result.WriteJSON(&buf, now, 2); err != nil {
+        t.Fatal(err)
+    }
+    var decoded exportDocument
+    if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+        t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+    }
+    if len(decoded.Jobs) != 2 {
+        t.Fatalf("got %d jobs, want 2", len(decoded.Jobs))
+    }
+    job := decoded.Jobs[0]
+    if job.User != "alice" || job.Command != "/bin/report \"weekly\"" || !job.Enabled || job.Line != 2 {
+        t.Errorf("unexpected job %+v", job)
+    }
+    if job.Fields == nil || job.Fields.DayOfWeek != "MON-FRI" {
+        t.Errorf("fields %+v", job.Fields)
+    }
+    wantRuns := []string{"2026-03-09T09:00:00Z", "2026-03-10T09:00:00Z"}
+    if len(job.NextRuns) != 2 || job.NextRuns[0] != wantRuns[0] || job.NextRuns[1] != wantRuns[1] {
+        t.Errorf("next runs %v, want %v", job.NextRuns, wantRuns)
+    }
+    special := decoded.Jobs[1]
+    if special.Special != "@daily" || special.Enabled || special.Fields.Hour != "0" {
+        t.Errorf("unexpected special job %+v", special)
+    }
+
+    buf.Reset()
+    if err := result.WriteYAML(&buf, now, 1); err != nil {
+        t.Fatal(err)
+    }
+    want := `jobs:
+  - schedule: "0 9 * * MON-FRI"
+    fields:
+      minute: "0"
+      hour: "9"
+      day_of_month: "*"
+      month: "*"
+      day_of_week: "MON-FRI"
+    user: "alice"
+    command: "/bin/report \"weekly\""
+    description: "Every Monday through Friday at 9:00 AM"
+    enabled: true
+    line: 2
+    next_runs:
+      - "2026-03-09T09:00:00Z"
+  - schedule: "@daily"
+    special: "@daily"
+    fields:
+      minute: "0"
+      hour: "0"
+      day_of_month: "*"
+      month: "*"
+      day_of_week: "*"
+    user: "root"
+    command: "/bin/cleanup"
+    description: "Run once a day (00:00)"
+    enabled: false
+    line: 3
+    next_runs:
+      - "2026-03-07T00:00:00Z"
+`
+    if buf.String() != want {
+        t.Errorf("YAML\n%s\nwant\n%s", buf.String(), want)
+    }
+}
@@ -0,0 +1,185 @@
+package parser
+
+import (
+    "encoding/json"
+    "fmt"
+    "slices"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// JobSpec is one entry of a declarative job list.
+type JobSpec struct {
+    Schedule []string
+    User     string
+    Command  string
+    Enabled  bool
+    // Timezone is the CRON_TZ the job runs in, empty for the system's.
+    Timezone string
+}
+
+// Line is the crontab line for the job.
+func (spec JobSpec) Line() string {
+    text := FormatJobLine(spec.Schedule, spec.User, spec.Command)
+    if !spec.Enabled {
+        text = DisableLine(text)
+    }
+    return text
+}
+
+// ReadJobList reads a job list in the json or yaml format written by
+// WriteJSON and WriteYAML, or a plain list of jobs. Only schedule (or
+// fields), user, command, enabled and timezone are used, enabled
+// defaulting to true.
+func ReadJobList(data []byte, format string) ([]JobSpec, error) {
+    var tree interface{}
+    switch format {
+    case "json":
+        if err := json.Unmarshal(data, &tree); err != nil {
+            return nil, err
+        }
+    case "yaml":
+        var err error
+        if tree, err = decodeYAML(data); err != nil {
+            return nil, err
+        }
+    default:
+        return nil, fmt.Errorf("unknown format %q, use json or yaml", format)
+    }
+
+    var items []interface{}
+    switch t := tree.(type) {
+    case []interface{}:
+        items = t
+    case map[string]interface{}:
+        if err := knownKeys(t, documentKeys); err != nil {
+            return nil, err
+        }
+        jobs, ok := t["jobs"]
+        if !ok {
+            return nil, fmt.Errorf("no \"jobs\" list")
+        }
+        list, ok := jobs.([]interface{})
+        if !ok && jobs != nil {
+            return nil, fmt.Errorf("\"jobs\" must be a list")
+        }
+        items = list
+    case nil:
+        return nil, fmt.Errorf("no jobs, the file is empty")
+    default:
+        return nil, fmt.Errorf("expected a list of jobs")
+    }
+
+    specs := make([]JobSpec, 0, len(items))
+    for i, item := range items {
+        spec, err := jobSpec(item)
+        if err != nil {
+            return nil, fmt.Errorf("job %d: %v", i+1, err)
+        }
+        specs = append(specs, spec)
+    }
+    return specs, nil
+}
+
+// The keys of a job list. Those only written by the export, such as
+// description or next_runs, are accepted and ignored so that an export
+// reads back. Any other key is taken for a typo.
+var (
+    documentKeys = []string{"jobs"}
+    jobKeys      = []string{"schedule", "special", "fields", "user", "command", "enabled",
+        "description", "timezone", "source", "line", "next_runs"}
+    fieldKeys    = []string{"minute", "hour", "day_of_month", "month", "day_of_week"}
+)
+
+// knownKeys rejects a mapping with a key not in keys.
+func knownKeys(m map[string]interface{}, keys []string) error {
+    var unknown []string
+    for key := range m {
+        if !slices.Contains(keys, key) {
+            unknown = append(unknown, key)
+        }
+    }
+    if len(unknown) == 0 {
+        return nil
+    }
+    sort.Strings(unknown)
+    return fmt.Errorf("unknown key %q", unknown[0])
+}
+
+func jobSpec(item interface{}) (JobSpec, error) {
+    spec := JobSpec{Enabled: true}
+    m, ok := item.(map[string]interface{})
+    if !ok {
+        return spec, fmt.Errorf("expected a mapping")
+    }
+    if err := knownKeys(m, jobKeys); err != nil {
+        return spec, err
+    }
+    schedule, err := scalar(m, "schedule")
+    if err != nil {
+        return spec, err
+    }
+    spec.Schedule = strings.Fields(schedule)
+    if len(spec.Schedule) == 0 {
+        fields, ok := m["fields"].(map[string]interface{})
+        if !ok {
+            return spec, fmt.Errorf("no schedule")
+        }
+        if err := knownKeys(fields, fieldKeys); err != nil {
+            return spec, fmt.Errorf("fields: %v", err)
+        }
+        for _, name := range fieldKeys {
+            value, err := scalar(fields, name)
+            if err != nil {
+                return spec, err
+            }
+            if value == "" {
+                return spec, fmt.Errorf("fields: no %s", name)
+            }
+            spec.Schedule = append(spec.Schedule, value)
+        }
+    }
+    if spec.User, err = scalar(m, "user"); err != nil {
+        return spec, err
+    }
+    if spec.Command, err = scalar(m, "command"); err != nil {
+        return spec, err
+    }
+    if spec.Timezone, err = scalar(m, "timezone"); err != nil {
+        return spec, err
+    }
+    if strings.ContainsAny(spec.Command, "\r\n") {
+        return spec, fmt.Errorf("command must be a single line")
+    }
+    switch v := m["enabled"].(type) {
+    case nil:
+    case bool:
+        spec.Enabled = v
+    case string:
+        switch strings.ToLower(v) {
+        case "true", "yes", "on":
+        case "false", "no", "off":
+            spec.Enabled = false
+        default:
+            return spec, fmt.Errorf("enabled: expected true or false, got %q", v)
+        }
+    default:
+        return spec, fmt.Errorf("enabled: expected true or false")
+    }
+    return spec, nil
+}
+
+// scalar reads a string value, accepting numbers for schedule fields
+// written without quotes.
+func scalar(m map[string]interface{}, key string) (string, error) {
+    switch v := m[key].(type) {
+    case nil:
+        return "", nil
+    case string:
+        return strings.TrimSpace(v), nil
+    case float64:
+        return strconv.FormatFloat(v, 'f', -1, 64), nil
+    }
+    return "", fmt.Errorf("%s: expected a string", key)
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+    "bytes"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestReadJobListRoundTrip(t *testing.T) {
+    doc := ParseDocumentFormat([]byte("0 9 * * MON-FRI alice /bin/report \"weekly\" # it's: here\n# @daily root /bin/cleanup\nCRON_TZ=Asia/Tokyo\n30 6 * * * root /bin/sync\n"), SystemFormat)
+    result := doc.Result()
+    now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+    want := []JobSpec{
+        {[]string{"0", "9", "*", "*", "MON-FRI"}, "alice", "/bin/report \"weekly\" # it's: here", true, ""},
+        {[]string{"@daily"}, "root", "/bin/cleanup", false, ""},
+        {[]string{"30", "6", "*", "*", "*"}, "root", "/bin/sync", true, "Asia/Tokyo"},
+    }
+
+    var buf bytes.Buffer
+    if err := result.WriteJSON(&buf, now, 2); err != nil {
+        t.Fatal(err)
+    }
+    specs, err := ReadJobList(buf.Bytes(), "json")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(specs, want) {
+        t.Errorf("json: got %+v, want %+v", specs, want)
+    }
+
+    buf.Reset()
+    if err := result.WriteYAML(&buf, now, 2); err != nil {
+        t.Fatal(err)
+    }
+    specs, err = ReadJobList(buf.Bytes(), "yaml")
+    if err != nil {
+        t.Fatalf("%v\n%s", err, buf.String())
+    }
+    if !reflect.DeepEqual(specs, want) {
+        t.Errorf("yaml: got %+v, want %+v", specs, want)
+    }
+}
+
+func TestReadJobListHandWritten(t *testing.T) {
+    in := `# nightly jobs
+jobs:
+- schedule: '*/5 * * * *'   # every five minutes
+  command: /usr/bin/poll --quiet
+- fields:
+    minute: 30
+    hour: 2
+    day_of_month: "*"
+    month: "*"
+    day_of_week: 0
+  command: "/usr/bin/backup"
+  enabled: no
+`
+    specs, err := ReadJobList([]byte(in), "yaml")
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []JobSpec{
+        {[]string{"*/5", "*", "*", "*", "*"}, "", "/usr/bin/poll --quiet", true, ""},
+        {[]string{"30", "2", "*", "*", "0"}, "", "/usr/bin/backup", false, ""},
+    }
+    if !reflect.DeepEqual(specs, want) {
+        t.Errorf("got %+v, want %+v", specs, want)
+    }
+    if specs, err := ReadJobList([]byte("jobs: []\n"), "yaml"); err != nil || len(specs) != 0 {
+        t.Errorf("empty list: %v, %v", specs, err)
+    }
+
+    for _, bad := range []string{
+        "jobs:\n  - command: /bin/true\n",
+        "jobs:\n  - schedule: \"@daily\"\n    command: |\n      /bin/true\n",
+        "jobs:\n\t- schedule: \"@daily\"\n",
+        "jobs:\n  - schedule: \"@daily\"\n    enabled: maybe\n",
+        "",
+        "# nothing yet\n",
+        "job:\n  - schedule: \"@daily\"\n    command: /bin/true\n",
+        "jobs: []\nreplace: true\n",
+        "jobs:\n  - schedule: \"@daily\"\n    comand: /bin/true\n",
+        "jobs:\n  - fields:\n      minute: 0\n      hours: 2\n    command: /bin/true\n",
+    } {
+        if _, err := ReadJobList([]byte(bad), "yaml"); err == nil {
+            t.Errorf("accepted %q", bad)
+        }
+    }
+}
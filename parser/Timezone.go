@@ -0,0 +1,76 @@
+package parser
+
+import (
+    "strings"
+    "sync"
+    "time"
+)
+
+// ZoneVariable is the assignment cronie evaluates the schedules of the
+// jobs below it in. TZ only changes the environment of the commands.
+const ZoneVariable = "CRON_TZ"
+
+var zoneCache = struct {
+    sync.Mutex
+    locations map[string]*time.Location
+    errors    map[string]error
+}{locations: map[string]*time.Location{}, errors: map[string]error{}}
+
+// LoadZone resolves a time zone name from the tz database, "" and "Local"
+// being the system's zone. Results are cached, the list looks zones up on
+// every redraw.
+func LoadZone(name string) (*time.Location, error) {
+    if name == "" || name == "Local" {
+        return time.Local, nil
+    }
+    zoneCache.Lock()
+    defer zoneCache.Unlock()
+    if loc, ok := zoneCache.locations[name]; ok {
+        return loc, nil
+    }
+    if err, ok := zoneCache.errors[name]; ok {
+        return nil, err
+    }
+    loc, err := time.LoadLocation(name)
+    if err != nil {
+        zoneCache.errors[name] = err
+        return nil, err
+    }
+    zoneCache.locations[name] = loc
+    return loc, nil
+}
+
+// Zone returns the CRON_TZ in effect for the job, empty when it follows
+// the system's time zone.
+func (job *CronJob) Zone() string {
+    return job.Env[ZoneVariable]
+}
+
+// Location returns the zone the job's schedule is evaluated in. Like cron,
+// an unknown CRON_TZ falls back to the system's zone.
+func (job *CronJob) Location() *time.Location {
+    loc, err := LoadZone(job.Zone())
+    if err != nil {
+        return time.Local
+    }
+    return loc
+}
+
+// Shifted reports whether a run of the job happens at another wall clock
+// time than scheduled, because DST skipped the scheduled one.
+func (job *CronJob) Shifted(run time.Time) bool {
+    return job.Spec != nil && !run.IsZero() && !job.Spec.Matches(run.In(job.Location()))
+}
+
+// DescribeIn is Describe for a job evaluated in the given CRON_TZ. An
+// unknown zone is not mentioned, cron ignores it.
+func DescribeIn(fields []string, zone string) string {
+    description := Describe(fields)
+    if zone == "" || len(fields) == 1 && strings.EqualFold(fields[0], "@reboot") {
+        return description
+    }
+    if _, err := LoadZone(zone); err != nil {
+        return description
+    }
+    return description + " (" + zone + " time)"
+}
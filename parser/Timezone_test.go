@@ -0,0 +1,183 @@
+package parser
+
+import (
+    "os"
+    "testing"
+    "time"
+    _ "time/tzdata"
+)
+
+// Jobs without a CRON_TZ run in the system's zone, pin it so that the
+// expected times do not depend on the host.
+func TestMain(m *testing.M) {
+    time.Local = time.UTC
+    os.Exit(m.Run())
+}
+
+func mustZone(t *testing.T, name string) *time.Location {
+    t.Helper()
+    loc, err := LoadZone(name)
+    if err != nil {
+        t.Fatalf("load %s: %v", name, err)
+    }
+    return loc
+}
+
+func TestScheduleNextDST(t *testing.T) {
+    newYork := mustZone(t, "America/New_York")
+    saoPaulo := mustZone(t, "America/Sao_Paulo")
+    lordHowe := mustZone(t, "Australia/Lord_Howe")
+    utc := func(year int, month time.Month, day, hour, min int) time.Time {
+        return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+    }
+
+    tests := []struct {
+        name   string
+        fields []string
+        loc    *time.Location
+        after  time.Time
+        want   time.Time
+    }{
+        // Clocks go from 02:00 EST to 03:00 EDT on 2026-03-08 (07:00 UTC).
+        {"gap fixed time runs at the jump", []string{"30", "2", "*", "*", "*"}, newYork, utc(2026, 3, 7, 17, 0), utc(2026, 3, 8, 7, 0)},
+        {"gap fixed time runs once", []string{"30", "2", "*", "*", "*"}, newYork, utc(2026, 3, 8, 7, 0), utc(2026, 3, 9, 6, 30)},
+        {"gap several fixed times run once", []string{"0,30", "2", "*", "*", "*"}, newYork, utc(2026, 3, 8, 7, 0), utc(2026, 3, 9, 6, 0)},
+        {"gap wildcard follows the clock", []string{"*/30", "*", "*", "*", "*"}, newYork, utc(2026, 3, 8, 6, 45), utc(2026, 3, 8, 7, 0)},
+        {"gap wildcard hour skips", []string{"30", "*", "*", "*", "*"}, newYork, utc(2026, 3, 8, 6, 45), utc(2026, 3, 8, 7, 30)},
+        {"around the gap", []string{"30", "3", "*", "*", "*"}, newYork, utc(2026, 3, 8, 6, 45), utc(2026, 3, 8, 7, 30)},
+        // Clocks go from 02:00 EDT back to 01:00 EST on 2026-11-01 (06:00 UTC).
+        {"overlap fixed time first pass", []string{"30", "1", "*", "*", "*"}, newYork, utc(2026, 10, 31, 16, 0), utc(2026, 11, 1, 5, 30)},
+        {"overlap fixed time runs once", []string{"30", "1", "*", "*", "*"}, newYork, utc(2026, 11, 1, 5, 30), utc(2026, 11, 2, 6, 30)},
+        {"overlap wildcard hour runs twice", []string{"30", "*", "*", "*", "*"}, newYork, utc(2026, 11, 1, 5, 30), utc(2026, 11, 1, 6, 30)},
+        {"overlap every minute", []string{"*", "*", "*", "*", "*"}, newYork, utc(2026, 11, 1, 5, 59), utc(2026, 11, 1, 6, 0)},
+        {"after the overlap", []string{"0", "2", "*", "*", "*"}, newYork, utc(2026, 11, 1, 5, 30), utc(2026, 11, 1, 7, 0)},
+        // Midnight did not exist on 2018-11-04, clocks went to 01:00.
+        {"gap at midnight", []string{"0", "0", "*", "*", "*"}, saoPaulo, utc(2018, 11, 3, 15, 0), utc(2018, 11, 4, 3, 0)},
+        // A 30 minute shift, 02:00 to 02:30 on 2026-10-04.
+        {"half hour gap", []string{"15", "2", "*", "*", "*"}, lordHowe, utc(2026, 10, 3, 0, 0), utc(2026, 10, 3, 15, 30)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s, err := ParseSchedule(tt.fields)
+            if err != nil {
+                t.Fatalf("parse: %v", err)
+            }
+            got := s.Next(tt.after.In(tt.loc))
+            if !got.Equal(tt.want) {
+                t.Fatalf("Next(%v) = %v, want %v", tt.after.In(tt.loc), got, tt.want.In(tt.loc))
+            }
+            if got.Location() != tt.loc {
+                t.Fatalf("Next returned a time in %v, want %v", got.Location(), tt.loc)
+            }
+        })
+    }
+}
+
+func TestSchedulePrevDST(t *testing.T) {
+    newYork := mustZone(t, "America/New_York")
+    utc := func(year int, month time.Month, day, hour, min int) time.Time {
+        return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+    }
+
+    tests := []struct {
+        name   string
+        fields []string
+        before time.Time
+        want   time.Time
+    }{
+        // Clocks go from 02:00 EST to 03:00 EDT on 2026-03-08 (07:00 UTC).
+        {"gap fixed time ran at the jump", []string{"30", "2", "*", "*", "*"}, utc(2026, 3, 8, 7, 30), utc(2026, 3, 8, 7, 0)},
+        {"gap before the jump", []string{"30", "2", "*", "*", "*"}, utc(2026, 3, 8, 7, 0), utc(2026, 3, 7, 7, 30)},
+        {"gap wildcard hour skips", []string{"30", "*", "*", "*", "*"}, utc(2026, 3, 8, 7, 20), utc(2026, 3, 8, 6, 30)},
+        // Clocks go from 02:00 EDT back to 01:00 EST on 2026-11-01 (06:00 UTC).
+        {"overlap fixed time first pass only", []string{"30", "1", "*", "*", "*"}, utc(2026, 11, 1, 6, 45), utc(2026, 11, 1, 5, 30)},
+        {"overlap wildcard hour second pass", []string{"30", "*", "*", "*", "*"}, utc(2026, 11, 1, 6, 45), utc(2026, 11, 1, 6, 30)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s, err := ParseSchedule(tt.fields)
+            if err != nil {
+                t.Fatalf("parse: %v", err)
+            }
+            if got := s.Prev(tt.before.In(newYork)); !got.Equal(tt.want) {
+                t.Fatalf("Prev(%v) = %v, want %v", tt.before.In(newYork), got, tt.want.In(newYork))
+            }
+            // Prev and Next agree: the run after the previous one is the
+            // first one not before the given time.
+            if next := s.Next(tt.want.In(newYork)); next.Before(tt.before) {
+                t.Fatalf("Next(%v) = %v, before %v", tt.want.In(newYork), next, tt.before.In(newYork))
+            }
+        })
+    }
+}
+
+func TestScheduleOverlapDST(t *testing.T) {
+    newYork := mustZone(t, "America/New_York")
+    parse := func(fields ...string) *CronSchedule {
+        s, err := ParseSchedule(fields)
+        if err != nil {
+            t.Fatalf("parse: %v", err)
+        }
+        return s
+    }
+    after := time.Date(2026, 3, 7, 17, 0, 0, 0, time.UTC).In(newYork)
+
+    // 02:30 does not exist on 2026-03-08 and runs at the jump to 03:00 EDT.
+    want := time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC)
+    if got := parse("30", "2", "*", "*", "*").Overlap(parse("0", "3", "*", "*", "*"), after); !got.Equal(want) {
+        t.Errorf("Overlap = %v, want %v", got, want.In(newYork))
+    }
+    if got := parse("0", "3", "*", "*", "*").Overlap(parse("30", "2", "*", "*", "*"), after); !got.Equal(want) {
+        t.Errorf("reversed Overlap = %v, want %v", got, want.In(newYork))
+    }
+    // Without the gap the two never meet.
+    if got := parse("30", "2", "*", "*", "*").Overlap(parse("0", "3", "*", "*", "*"), after.In(time.UTC)); !got.IsZero() {
+        t.Errorf("Overlap in UTC = %v, want none", got)
+    }
+}
+
+func TestJobZone(t *testing.T) {
+    doc := ParseDocument([]byte("0 9 * * * /bin/local\nCRON_TZ=America/New_York\n0 9 * * * /bin/newyork\nCRON_TZ=Mars/Olympus\n0 9 * * * /bin/unknown\n"))
+    jobs := doc.Result().CronJobs
+    if len(jobs) != 3 {
+        t.Fatalf("got %d jobs, want 3", len(jobs))
+    }
+    if jobs[0].Location() != time.Local || jobs[2].Location() != time.Local {
+        t.Fatalf("jobs without a known CRON_TZ should run in the system zone")
+    }
+    if got := jobs[1].Location().String(); got != "America/New_York" {
+        t.Fatalf("Location() = %s, want America/New_York", got)
+    }
+    if got, want := jobs[1].Description, "Every day at 9:00 AM (America/New_York time)"; got != want {
+        t.Fatalf("Description = %q, want %q", got, want)
+    }
+
+    // 08:00 EDT, shown in UTC.
+    after := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+    next := jobs[1].NextRun(after)
+    if want := time.Date(2026, 10, 18, 13, 0, 0, 0, time.UTC); !next.Equal(want) || next.Location() != time.UTC {
+        t.Fatalf("NextRun = %v, want %v", next, want)
+    }
+    runs := jobs[1].NextRuns(after, 2)
+    if len(runs) != 2 || !runs[1].Equal(time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC)) {
+        t.Fatalf("NextRuns = %v", runs)
+    }
+}
+
+func TestJobShifted(t *testing.T) {
+    doc := ParseDocument([]byte("CRON_TZ=Europe/Berlin\n30 2 * * * /bin/job\n"))
+    job := doc.Result().CronJobs[0]
+    // Clocks go from 02:00 to 03:00 CEST on 2026-03-29.
+    runs := job.NextRuns(time.Date(2026, 3, 28, 12, 0, 0, 0, time.UTC), 2)
+    if len(runs) != 2 {
+        t.Fatalf("got %d runs", len(runs))
+    }
+    if !runs[0].Equal(time.Date(2026, 3, 29, 1, 0, 0, 0, time.UTC)) || !job.Shifted(runs[0]) {
+        t.Fatalf("first run %v should be moved to the end of the gap", runs[0])
+    }
+    if job.Shifted(runs[1]) {
+        t.Fatalf("second run %v is on time", runs[1])
+    }
+}
@@ -0,0 +1,253 @@
+package parser
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// The YAML needed for job lists is small: block mappings and sequences,
+// plain and quoted scalars, comments and empty flow collections. decodeYAML
+// reads that subset into the same kind of tree encoding/json produces for
+// an interface{}, except that every scalar is a string or nil.
+
+type yamlLine struct {
+    number int
+    indent int
+    text   string
+}
+
+type yamlDecoder struct {
+    lines []yamlLine
+    pos   int
+}
+
+func decodeYAML(data []byte) (interface{}, error) {
+    d := &yamlDecoder{}
+    for i, raw := range strings.Split(string(data), "\n") {
+        raw = strings.TrimRight(raw, "\r")
+        if strings.TrimLeft(raw, "\t ") != strings.TrimLeft(raw, " ") {
+            return nil, fmt.Errorf("line %d: tabs cannot indent YAML", i+1)
+        }
+        text := strings.TrimRight(stripYAMLComment(raw), " ")
+        trimmed := strings.TrimLeft(text, " ")
+        if trimmed == "" || trimmed == "---" || trimmed == "..." {
+            continue
+        }
+        d.lines = append(d.lines, yamlLine{i + 1, len(text) - len(trimmed), trimmed})
+    }
+    if len(d.lines) == 0 {
+        return nil, nil
+    }
+    node, err := d.node(d.lines[0].indent)
+    if err != nil {
+        return nil, err
+    }
+    if d.pos < len(d.lines) {
+        return nil, fmt.Errorf("line %d: unexpected indentation", d.lines[d.pos].number)
+    }
+    return node, nil
+}
+
+// stripYAMLComment removes a # comment that is not inside quotes.
+func stripYAMLComment(s string) string {
+    var quote byte
+    for i := 0; i < len(s); i++ {
+        c := s[i]
+        switch {
+        case quote == '"' && c == '\\':
+            i++
+        case quote != 0:
+            if c == quote {
+                quote = 0
+            }
+        case c == '"' || c == '\'':
+            if i == 0 || s[i-1] == ' ' || s[i-1] == ':' || s[i-1] == '-' || s[i-1] == '[' || s[i-1] == ',' {
+                quote = c
+            }
+        case c == '#' && (i == 0 || s[i-1] == ' '):
+            return s[:i]
+        }
+    }
+    return s
+}
+
+// node parses the block starting at the current line, which is indented
+// by indent.
+func (d *yamlDecoder) node(indent int) (interface{}, error) {
+    line := d.lines[d.pos]
+    if line.text == "-" || strings.HasPrefix(line.text, "- ") {
+        return d.sequence(indent)
+    }
+    if _, _, ok := splitYAMLKey(line.text); ok {
+        return d.mapping(indent)
+    }
+    d.pos++
+    return yamlScalar(line.text, line.number)
+}
+
+func (d *yamlDecoder) sequence(indent int) (interface{}, error) {
+    items := []interface{}{}
+    for d.pos < len(d.lines) {
+        line := d.lines[d.pos]
+        if line.indent != indent || !(line.text == "-" || strings.HasPrefix(line.text, "- ")) {
+            break
+        }
+        rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+        if rest == "" {
+            d.pos++
+            item, err := d.child(indent)
+            if err != nil {
+                return nil, err
+            }
+            items = append(items, item)
+            continue
+        }
+        // "- key: value" starts a mapping indented like its first key.
+        d.lines[d.pos] = yamlLine{line.number, indent + len(line.text) - len(rest), rest}
+        item, err := d.node(d.lines[d.pos].indent)
+        if err != nil {
+            return nil, err
+        }
+        items = append(items, item)
+    }
+    return items, nil
+}
+
+func (d *yamlDecoder) mapping(indent int) (interface{}, error) {
+    values := map[string]interface{}{}
+    for d.pos < len(d.lines) {
+        line := d.lines[d.pos]
+        if line.indent < indent {
+            break
+        }
+        if line.indent > indent {
+            return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+        }
+        key, value, ok := splitYAMLKey(line.text)
+        if !ok {
+            return nil, fmt.Errorf("line %d: expected \"key: value\"", line.number)
+        }
+        if _, dup := values[key]; dup {
+            return nil, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+        }
+        d.pos++
+        if value != "" {
+            scalar, err := yamlScalar(value, line.number)
+            if err != nil {
+                return nil, err
+            }
+            values[key] = scalar
+            continue
+        }
+        // A sequence may sit at the same indentation as its key.
+        if d.pos < len(d.lines) && d.lines[d.pos].indent == indent && strings.HasPrefix(d.lines[d.pos].text, "-") {
+            child, err := d.sequence(indent)
+            if err != nil {
+                return nil, err
+            }
+            values[key] = child
+            continue
+        }
+        child, err := d.child(indent)
+        if err != nil {
+            return nil, err
+        }
+        values[key] = child
+    }
+    return values, nil
+}
+
+// child parses the block nested below a line indented by indent, nil
+// when there is none.
+func (d *yamlDecoder) child(indent int) (interface{}, error) {
+    if d.pos >= len(d.lines) || d.lines[d.pos].indent <= indent {
+        return nil, nil
+    }
+    return d.node(d.lines[d.pos].indent)
+}
+
+// splitYAMLKey splits "key: value" and "key:".
+func splitYAMLKey(text string) (string, string, bool) {
+    if text[0] == '"' || text[0] == '\'' {
+        end := strings.IndexByte(text[1:], text[0])
+        if end < 0 {
+            return "", "", false
+        }
+        key, err := yamlScalar(text[:end+2], 0)
+        rest := text[end+2:]
+        if err != nil || !(rest == ":" || strings.HasPrefix(rest, ": ")) {
+            return "", "", false
+        }
+        return fmt.Sprint(key), strings.TrimSpace(rest[1:]), true
+    }
+    if i := strings.Index(text, ": "); i > 0 {
+        return text[:i], strings.TrimSpace(text[i+2:]), true
+    }
+    if strings.HasSuffix(text, ":") && len(text) > 1 {
+        return text[:len(text)-1], "", true
+    }
+    return "", "", false
+}
+
+func yamlScalar(text string, number int) (interface{}, error) {
+    switch {
+    case text == "":
+        return "", nil
+    case text == "~" || text == "null":
+        return nil, nil
+    case text == "[]":
+        return []interface{}{}, nil
+    case text == "{}":
+        return map[string]interface{}{}, nil
+    case strings.HasPrefix(text, "["):
+        return yamlFlowSequence(text, number)
+    case text[0] == '"':
+        s, err := strconv.Unquote(text)
+        if err != nil {
+            return nil, fmt.Errorf("line %d: invalid double quoted string %s", number, text)
+        }
+        return s, nil
+    case text[0] == '\'':
+        if len(text) < 2 || text[len(text)-1] != '\'' {
+            return nil, fmt.Errorf("line %d: unterminated single quoted string", number)
+        }
+        return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+    case text[0] == '|' || text[0] == '>' || text[0] == '{' || text[0] == '&' || text[0] == '*' || text[0] == '!':
+        return nil, fmt.Errorf("line %d: unsupported YAML %q", number, text)
+    }
+    return text, nil
+}
+
+// yamlFlowSequence handles one-line lists such as ["a", "b"].
+func yamlFlowSequence(text string, number int) (interface{}, error) {
+    if !strings.HasSuffix(text, "]") {
+        return nil, fmt.Errorf("line %d: unterminated flow sequence", number)
+    }
+    items := []interface{}{}
+    inner := strings.TrimSpace(text[1 : len(text)-1])
+    for inner != "" {
+        end := len(inner)
+        if inner[0] == '"' || inner[0] == '\'' {
+            close := 1
+            for close < len(inner) && inner[close] != inner[0] {
+                if inner[0] == '"' && inner[close] == '\\' {
+                    close++
+                }
+                close++
+            }
+            if comma := strings.IndexByte(inner[min(close, len(inner)):], ','); comma >= 0 {
+                end = close + comma
+            }
+        } else if comma := strings.IndexByte(inner, ','); comma >= 0 {
+            end = comma
+        }
+        item, err := yamlScalar(strings.TrimSpace(inner[:end]), number)
+        if err != nil {
+            return nil, err
+        }
+        items = append(items, item)
+        inner = strings.TrimSpace(strings.TrimPrefix(inner[end:], ","))
+    }
+    return items, nil
+}
@@ -0,0 +1,181 @@
+package runner
+
+import (
+    "fmt"
+    "io"
+    "os/user"
+    "sort"
+    "strings"
+    "crontab-tui/parser"
+    "crontab-tui/utils"
+)
+
+// LookupAccount returns the user cron runs the job as: the one in its user
+// column, else the owner of the crontab, else the user running
+// crontab-tui.
+func LookupAccount(job *parser.CronJob, owner string) (*utils.PasswdEntry, error) {
+    name := job.User
+    if name == "" {
+        name = owner
+    }
+    if name == "" {
+        u, err := user.Current()
+        if err != nil {
+            return nil, err
+        }
+        name = u.Username
+    }
+    return utils.LookupPasswd(name)
+}
+
+// Environment returns the environment cron starts the job with, in the
+// order cron builds it: the crontab's assignments in file order, then
+// SHELL, HOME and PATH unless the crontab set them, then LOGNAME and USER,
+// which the crontab cannot change. Nothing is inherited from the daemon.
+func Environment(job *parser.CronJob, account *utils.PasswdEntry) []string {
+    var env []string
+    set := func(name string, value string) {
+        for i, kv := range env {
+            if strings.HasPrefix(kv, name+"=") {
+                env[i] = name + "=" + value
+                return
+            }
+        }
+        env = append(env, name+"="+value)
+    }
+    for _, name := range variableOrder(job) {
+        set(name, job.Env[name])
+    }
+    for _, name := range []string{"SHELL", "HOME", "PATH"} {
+        if _, ok := job.Env[name]; ok {
+            continue
+        }
+        switch name {
+        case "HOME":
+            set(name, account.Home)
+        default:
+            set(name, parser.DefaultEnv[name])
+        }
+    }
+    set("LOGNAME", account.Name)
+    set("USER", account.Name)
+    return env
+}
+
+// variableOrder is job.EnvOrder, followed by any other name in job.Env
+// sorted.
+func variableOrder(job *parser.CronJob) []string {
+    names := append([]string(nil), job.EnvOrder...)
+    seen := map[string]bool{}
+    for _, name := range names {
+        seen[name] = true
+    }
+    var rest []string
+    for name := range job.Env {
+        if !seen[name] {
+            rest = append(rest, name)
+        }
+    }
+    sort.Strings(rest)
+    return append(names, rest...)
+}
+
+func lookup(env []string, name string) string {
+    for _, kv := range env {
+        if value, ok := strings.CutPrefix(kv, name+"="); ok {
+            return value
+        }
+    }
+    return ""
+}
+
+// EnvChange lines up one variable of cron's environment with another
+// environment.
+type EnvChange struct {
+    Name    string
+    Cron    string
+    Other   string
+    InCron  bool
+    InOther bool
+}
+
+func (c EnvChange) Same() bool {
+    return c.InCron && c.InOther && c.Cron == c.Other
+}
+
+// CompareEnvironment compares cron's environment with another one, e.g.
+// that of the user's shell: cron's variables in its order, then those it
+// lacks, sorted.
+func CompareEnvironment(cron []string, other []string) []EnvChange {
+    values := map[string]string{}
+    for _, kv := range other {
+        if name, value, ok := strings.Cut(kv, "="); ok {
+            values[name] = value
+        }
+    }
+    var changes []EnvChange
+    seen := map[string]bool{}
+    for _, kv := range cron {
+        name, value, _ := strings.Cut(kv, "=")
+        otherValue, ok := values[name]
+        changes = append(changes, EnvChange{Name: name, Cron: value, Other: otherValue, InCron: true, InOther: ok})
+        seen[name] = true
+    }
+    var missing []EnvChange
+    for name, value := range values {
+        if !seen[name] {
+            missing = append(missing, EnvChange{Name: name, Other: value, InOther: true})
+        }
+    }
+    sort.Slice(missing, func(i, j int) bool { return missing[i].Name < missing[j].Name })
+    return append(changes, missing...)
+}
+
+// nameWidth caps the name column of WriteComparison.
+const nameWidth = 24
+
+// WriteComparison writes the changes side by side, values cut to width.
+// The first column marks each line: = same, ~ different, < only in
+// cron's environment, > only in the other one.
+func WriteComparison(w io.Writer, changes []EnvChange, otherName string, width int, color bool) error {
+    names := len("NAME")
+    for _, c := range changes {
+        names = min(max(names, len(c.Name)), nameWidth)
+    }
+    if _, err := fmt.Fprintf(w, "  %-*s  %-*s  %s\n", names, "NAME", width, "CRON", otherName); err != nil {
+        return err
+    }
+    for _, c := range changes {
+        mark, code := "~", "33"
+        switch {
+        case c.Same():
+            mark, code = "=", ""
+        case !c.InOther:
+            mark, code = "<", "31"
+        case !c.InCron:
+            mark, code = ">", "32"
+        }
+        cronValue, otherValue := "(unset)", "(unset)"
+        if c.InCron {
+            cronValue = c.Cron
+        }
+        if c.InOther {
+            otherValue = c.Other
+        }
+        line := fmt.Sprintf("%s %-*s  %-*s  %s", mark, names, cut(c.Name, names), width, cut(cronValue, width), cut(otherValue, width))
+        if color && code != "" {
+            line = "\033[" + code + "m" + line + "\033[0m"
+        }
+        if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func cut(s string, width int) string {
+    if len([]rune(s)) <= width {
+        return s
+    }
+    return string([]rune(s)[:width-1]) + "…"
+}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+    "crontab-tui/parser"
+    "crontab-tui/utils"
+)
+
+func TestEnvironment(t *testing.T) {
+    passwd := filepath.Join(t.TempDir(), "passwd")
+    if err := os.WriteFile(passwd, []byte("alice:x:1000:1000::/home/alice:/bin/zsh\nbob:x:1001:1001::/srv/bob:/bin/bash\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    defer func(old string) { utils.PasswdFile = old }(utils.PasswdFile)
+    utils.PasswdFile = passwd
+
+    doc := parser.ParseDocument([]byte("MAILTO=ops\nPATH=/opt/bin:/usr/bin:/bin\nLOGNAME=root\nMAILTO=dev\n0 * * * * /bin/job\nHOME=/tmp\n0 * * * * /bin/other\n"))
+    jobs := doc.Result().CronJobs
+
+    account, err := LookupAccount(&jobs[0], "alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []string{
+        "MAILTO=dev",
+        "PATH=/opt/bin:/usr/bin:/bin",
+        "LOGNAME=alice",
+        "SHELL=/bin/sh",
+        "HOME=/home/alice",
+        "USER=alice",
+    }
+    if got := Environment(&jobs[0], account); !reflect.DeepEqual(got, want) {
+        t.Errorf("Environment = %q, want %q", got, want)
+    }
+    if got := lookup(Environment(&jobs[1], account), "HOME"); got != "/tmp" {
+        t.Errorf("HOME set in the crontab = %q, want /tmp", got)
+    }
+
+    // The user column wins over the owner.
+    jobs[0].User = "bob"
+    if account, err := LookupAccount(&jobs[0], "alice"); err != nil || account.Home != "/srv/bob" {
+        t.Errorf("LookupAccount = %+v, %v", account, err)
+    }
+    jobs[0].User = "nobody-here"
+    if _, err := LookupAccount(&jobs[0], ""); err == nil {
+        t.Errorf("expected an error for an unknown user")
+    }
+}
+
+func TestCompareEnvironment(t *testing.T) {
+    cron := []string{"PATH=/usr/bin:/bin", "SHELL=/bin/sh", "HOME=/home/alice", "CRON_ONLY=1"}
+    shell := []string{"TERM=xterm", "HOME=/home/alice", "PATH=/usr/local/bin:/usr/bin:/bin", "SHELL=/bin/zsh", "EDITOR=vi"}
+    changes := CompareEnvironment(cron, shell)
+
+    var buf bytes.Buffer
+    if err := WriteComparison(&buf, changes, "SHELL", 14, false); err != nil {
+        t.Fatal(err)
+    }
+    want := `  NAME       CRON            SHELL
+~ PATH       /usr/bin:/bin   /usr/local/bi…
+~ SHELL      /bin/sh         /bin/zsh
+= HOME       /home/alice     /home/alice
+< CRON_ONLY  1               (unset)
+> EDITOR     (unset)         vi
+> TERM       (unset)         xterm
+`
+    if buf.String() != want {
+        t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+    }
+}
@@ -0,0 +1,174 @@
+package runner
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "strings"
+    "sync"
+    "time"
+    "crontab-tui/parser"
+    "crontab-tui/utils"
+)
+
+// KillTimeout is how long a canceled job gets to exit after SIGTERM before
+// it is killed.
+var KillTimeout = 3 * time.Second
+
+// Result is how a run ended.
+type Result struct {
+    ExitCode int
+    Duration time.Duration
+    Canceled bool
+    // Err is set when the command could not be started or waited for, or
+    // was ended by a signal.
+    Err error
+}
+
+func (r Result) String() string {
+    duration := r.Duration.Round(time.Millisecond)
+    switch {
+    case r.Canceled:
+        return fmt.Sprintf("canceled after %v", duration)
+    case r.Err != nil:
+        return fmt.Sprintf("%v after %v", r.Err, duration)
+    }
+    return fmt.Sprintf("exit status %d after %v", r.ExitCode, duration)
+}
+
+// Run is one execution of a job's command, set up the way cron starts it.
+type Run struct {
+    Shell    string
+    Command  string
+    Input    string
+    HasInput bool
+    Dir      string
+    Env      []string
+    Account  *utils.PasswdEntry
+
+    cancelOnce sync.Once
+    canceled   chan struct{}
+    done       chan struct{}
+    result     Result
+}
+
+// New prepares a run of the job, set up like cron would: its command,
+// with % turned into standard input, run by the crontab's SHELL in HOME
+// with cron's environment. owner is the user a user crontab belongs to,
+// see LookupAccount.
+func New(job *parser.CronJob, owner string) (*Run, error) {
+    account, err := LookupAccount(job, owner)
+    if err != nil {
+        return nil, err
+    }
+    run, input, hasInput := parser.SplitCommand(job.Command)
+    env := Environment(job, account)
+    return &Run{
+        Shell:    lookup(env, "SHELL"),
+        Command:  run,
+        Input:    input,
+        HasInput: hasInput,
+        Dir:      lookup(env, "HOME"),
+        Env:      env,
+        Account:  account,
+        canceled: make(chan struct{}),
+        done:     make(chan struct{}),
+    }, nil
+}
+
+// AsAccount reports whether the command runs as the job's user. Switching
+// users needs root, otherwise it runs as the user running crontab-tui.
+func (r *Run) AsAccount() bool {
+    euid := os.Geteuid()
+    return euid == r.Account.UID || euid == 0
+}
+
+// Start runs the command in the background. Standard output and error are
+// passed to output as they come, finished is called once the command
+// exited and all its output was passed on.
+func (r *Run) Start(output func([]byte), finished func(Result)) error {
+    cmd := exec.Command(r.Shell, "-c", r.Command)
+    cmd.Dir = r.Dir
+    cmd.Env = r.Env
+    if r.HasInput {
+        cmd.Stdin = strings.NewReader(r.Input)
+    }
+    reader, writer, err := os.Pipe()
+    if err != nil {
+        return err
+    }
+    cmd.Stdout = writer
+    cmd.Stderr = writer
+    setProcessGroup(cmd)
+    if os.Geteuid() == 0 && r.Account.UID != 0 {
+        runAs(cmd, r.Account.Name, r.Account.UID, r.Account.GID)
+    }
+    start := time.Now()
+    if err := cmd.Start(); err != nil {
+        reader.Close()
+        writer.Close()
+        return err
+    }
+    writer.Close()
+
+    go func() {
+        select {
+        case <-r.canceled:
+        case <-r.done:
+            return
+        }
+        signalGroup(cmd, false)
+        select {
+        case <-time.After(KillTimeout):
+            signalGroup(cmd, true)
+        case <-r.done:
+        }
+    }()
+
+    go func() {
+        // Read until every process holding the pipe is gone, as cron
+        // does before it mails the output.
+        buf := make([]byte, 4096)
+        for {
+            n, err := reader.Read(buf)
+            if n > 0 {
+                chunk := make([]byte, n)
+                copy(chunk, buf[:n])
+                output(chunk)
+            }
+            if err != nil {
+                break
+            }
+        }
+        reader.Close()
+        err := cmd.Wait()
+        r.result = Result{ExitCode: cmd.ProcessState.ExitCode(), Duration: time.Since(start)}
+        select {
+        case <-r.canceled:
+            r.result.Canceled = true
+        default:
+            var exitErr *exec.ExitError
+            if err != nil && (!errors.As(err, &exitErr) || r.result.ExitCode < 0) {
+                r.result.Err = err
+            }
+        }
+        close(r.done)
+        finished(r.result)
+    }()
+    return nil
+}
+
+// Cancel stops the command and whatever it started, first asking them to
+// terminate.
+func (r *Run) Cancel() {
+    r.cancelOnce.Do(func() {
+        close(r.canceled)
+    })
+}
+
+// Wait blocks until the command has exited and returns how it ended.
+func (r *Run) Wait() Result {
+    <-r.done
+    return r.result
+}
@@ -0,0 +1,84 @@
+package runner
+
+import (
+    "bytes"
+    "sync"
+    "testing"
+    "time"
+    "crontab-tui/parser"
+)
+
+// collect runs the job to the end and returns its output.
+func collect(t *testing.T, r *Run, during func()) (string, Result) {
+    t.Helper()
+    var mu sync.Mutex
+    var out bytes.Buffer
+    finished := make(chan Result, 1)
+    err := r.Start(func(b []byte) {
+        mu.Lock()
+        out.Write(b)
+        mu.Unlock()
+    }, func(result Result) {
+        finished <- result
+    })
+    if err != nil {
+        t.Fatalf("start: %v", err)
+    }
+    if during != nil {
+        during()
+    }
+    select {
+    case result := <-finished:
+        mu.Lock()
+        defer mu.Unlock()
+        return out.String(), result
+    case <-time.After(10 * time.Second):
+        t.Fatal("the run did not finish")
+    }
+    return "", Result{}
+}
+
+func TestRun(t *testing.T) {
+    job := &parser.CronJob{
+        Command: `echo "$GREETING $SHELL $PATH"; cat; echo 100\% >&2; exit 3%line one%line two`,
+        Env:     map[string]string{"GREETING": "hi"},
+    }
+    r, err := New(job, "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !r.HasInput || r.Input != "line one\nline two" {
+        t.Fatalf("input %q", r.Input)
+    }
+    out, result := collect(t, r, nil)
+    if want := "hi /bin/sh /usr/bin:/bin\nline one\nline two100%\n"; out != want {
+        t.Errorf("output %q, want %q", out, want)
+    }
+    if result.ExitCode != 3 || result.Err != nil || result.Canceled {
+        t.Errorf("result %+v", result)
+    }
+    if r.Wait() != result {
+        t.Errorf("Wait() differs from the result passed to finished")
+    }
+}
+
+func TestRunCancel(t *testing.T) {
+    KillTimeout = 500 * time.Millisecond
+    // The shell ignores SIGTERM, its child does not.
+    r, err := New(&parser.CronJob{Command: "trap '' TERM; echo started; sleep 30 & wait; sleep 30"}, "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    start := time.Now()
+    out, result := collect(t, r, func() {
+        time.Sleep(200 * time.Millisecond)
+        r.Cancel()
+        r.Cancel()
+    })
+    if out != "started\n" {
+        t.Errorf("output %q", out)
+    }
+    if !result.Canceled || time.Since(start) > 5*time.Second {
+        t.Errorf("result %+v after %v", result, time.Since(start))
+    }
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package runner
+
+import (
+    "os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+func signalGroup(cmd *exec.Cmd, kill bool) {
+    cmd.Process.Kill()
+}
+
+func runAs(cmd *exec.Cmd, name string, uid int, gid int) {
+}
@@ -0,0 +1,39 @@
+//go:build unix
+
+package runner
+
+import (
+    "os/exec"
+    "os/user"
+    "strconv"
+    "syscall"
+)
+
+// setProcessGroup puts the command in a group of its own, so that a
+// cancel reaches the processes the shell started as well.
+func setProcessGroup(cmd *exec.Cmd) {
+    cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func signalGroup(cmd *exec.Cmd, kill bool) {
+    signal := syscall.SIGTERM
+    if kill {
+        signal = syscall.SIGKILL
+    }
+    syscall.Kill(-cmd.Process.Pid, signal)
+}
+
+// runAs starts the command as another user, with the supplementary
+// groups cron's initgroups gives it.
+func runAs(cmd *exec.Cmd, name string, uid int, gid int) {
+    groups := []uint32{}
+    if u, err := user.Lookup(name); err == nil {
+        ids, _ := u.GroupIds()
+        for _, id := range ids {
+            if n, err := strconv.Atoi(id); err == nil {
+                groups = append(groups, uint32(n))
+            }
+        }
+    }
+    cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: groups}
+}
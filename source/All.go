@@ -0,0 +1,261 @@
+package source
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strings"
+    "crontab-tui/parser"
+)
+
+// Spool directories holding per-user crontabs, Debian first, then the
+// Red Hat layout where the files sit directly in /var/spool/cron.
+var SpoolDirs = []string{"/var/spool/cron/crontabs", "/var/spool/cron"}
+
+// The run-parts directories. When they run comes from the system crontab
+// line calling run-parts on them, anacron runs them when there is none.
+var RunPartsDirs = []string{"/etc/cron.hourly", "/etc/cron.daily", "/etc/cron.weekly", "/etc/cron.monthly"}
+
+// Anacron is where Debian's /etc/crontab looks for anacron, its run-parts
+// lines only run the directories when it is missing.
+const Anacron = "/usr/sbin/anacron"
+
+// AllSources returns every place cron takes jobs from below root ("/" on
+// a live system): the user spool, /etc/crontab, /etc/cron.d/* and the
+// run-parts directories.
+func AllSources(root string) ([]Source, error) {
+    sources := []Source{}
+
+    for _, dir := range SpoolDirs {
+        names, err := cronDirFiles(filepath.Join(root, dir))
+        if err != nil {
+            return nil, err
+        }
+        for _, name := range names {
+            sources = append(sources, NewSpoolFileSource(filepath.Join(root, dir, name), name))
+        }
+    }
+
+    system, err := SystemSources(root)
+    if err != nil {
+        return nil, err
+    }
+    sources = append(sources, system...)
+
+    for _, name := range RunPartsDirs {
+        dir := filepath.Join(root, name)
+        if info, err := os.Stat(dir); err == nil && info.IsDir() {
+            sources = append(sources, NewRunPartsSource(dir, runPartsTrigger(root, system, name)))
+        }
+    }
+    return sources, nil
+}
+
+// runPartsTrigger finds the enabled job of the system crontabs that calls
+// run-parts on dir, nil when there is none or it defers to anacron.
+func runPartsTrigger(root string, system []Source, dir string) *parser.CronJob {
+    _, err := os.Stat(filepath.Join(root, Anacron))
+    hasAnacron := err == nil
+    for _, src := range system {
+        result, err := Load(src)
+        if err != nil {
+            continue
+        }
+        for i := range result.CronJobs {
+            job := &result.CronJobs[i]
+            if job.Err != nil || job.Disabled || !runsParts(job.Command, dir) {
+                continue
+            }
+            if hasAnacron && strings.Contains(job.Command, Anacron) {
+                return nil
+            }
+            return job
+        }
+    }
+    return nil
+}
+
+// runsParts reports whether command calls run-parts with dir, as in
+// "cd / && run-parts --report /etc/cron.daily".
+func runsParts(command string, dir string) bool {
+    called := false
+    for _, word := range strings.Fields(command) {
+        word = strings.TrimRight(word, ";&|)}")
+        switch {
+        case filepath.Base(word) == "run-parts":
+            called = true
+        case called && strings.TrimSuffix(word, "/") == dir:
+            return true
+        }
+    }
+    return false
+}
+
+// Load reads one source into a Result whose jobs carry their origin.
+func Load(src Source) (*parser.Result, error) {
+    data, err := src.Read()
+    if err != nil {
+        return nil, err
+    }
+    return Parse(src, data), nil
+}
+
+// Parse is Load for content already read from src.
+func Parse(src Source, data []byte) *parser.Result {
+    if rp, ok := unwrap(src).(*RunPartsSource); ok {
+        return rp.parse(data)
+    }
+    result := parser.ParseDocumentFormat(data, src.Format()).Result()
+    origin := Origin(src)
+    owner := ""
+    if fs, ok := unwrap(src).(*FileSource); ok {
+        owner = fs.owner
+    }
+    for i := range result.CronJobs {
+        job := &result.CronJobs[i]
+        job.Source = origin
+        if job.User == "" {
+            job.User = owner
+        }
+    }
+    return result
+}
+
+// LoadAll merges the jobs of every source in order. Sources that cannot be
+// read are skipped and reported in the returned errors.
+func LoadAll(sources []Source) (*parser.Result, []error) {
+    merged := &parser.Result{CronJobs: make([]parser.CronJob, 0), Aggregated: true}
+    var errs []error
+    for _, src := range sources {
+        result, err := Load(src)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("%s: %w", src.Name(), err))
+            continue
+        }
+        merged.CronJobs = append(merged.CronJobs, result.CronJobs...)
+    }
+    return merged, errs
+}
+
+// Origin is the file (or directory) a source's jobs are attributed to.
+func Origin(src Source) string {
+    if path := src.Path(); path != "" {
+        return path
+    }
+    if rp, ok := unwrap(src).(*RunPartsSource); ok {
+        return rp.dir
+    }
+    return src.Name()
+}
+
+// Owner returns the user the jobs of a user crontab run as, empty when it
+// is the user running crontab-tui. Jobs of system crontabs name theirs.
+func Owner(src Source) string {
+    switch s := unwrap(src).(type) {
+    case *FileSource:
+        return s.owner
+    case *CommandSource:
+        return s.User
+    }
+    return ""
+}
+
+// unwrap strips the read-only and backup wrappers.
+func unwrap(src Source) Source {
+    for {
+        switch wrapped := src.(type) {
+        case readOnlySource:
+            src = wrapped.Source
+        case *Backed:
+            src = wrapped.Source
+        default:
+            return src
+        }
+    }
+}
+
+// RunPartsSource presents the scripts of a run-parts directory such as
+// /etc/cron.daily as jobs run as root, on the schedule of the system
+// crontab line running the directory, its trigger.
+type RunPartsSource struct {
+    dir     string
+    trigger *parser.CronJob
+}
+
+// NewRunPartsSource returns the source for dir, trigger being nil when
+// anacron runs it.
+func NewRunPartsSource(dir string, trigger *parser.CronJob) *RunPartsSource {
+    return &RunPartsSource{dir: dir, trigger: trigger}
+}
+
+func (s *RunPartsSource) Name() string {
+    return s.dir
+}
+
+// Path is empty, the directory's content is polled instead.
+func (s *RunPartsSource) Path() string {
+    return ""
+}
+
+func (s *RunPartsSource) Format() parser.Format {
+    return parser.SystemFormat
+}
+
+// run-parts only runs names made of these characters (Debian's default
+// LSB/Debian namespace rules aside).
+var runPartsName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Read lists the scripts run-parts would run, one path per line.
+func (s *RunPartsSource) Read() ([]byte, error) {
+    entries, err := os.ReadDir(s.dir)
+    if err != nil {
+        return nil, err
+    }
+    var names []string
+    for _, entry := range entries {
+        if !runPartsName.MatchString(entry.Name()) {
+            continue
+        }
+        info, err := entry.Info()
+        if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+            continue
+        }
+        names = append(names, entry.Name())
+    }
+    sort.Strings(names)
+
+    var b strings.Builder
+    for _, name := range names {
+        fmt.Fprintln(&b, filepath.Join(s.dir, name))
+    }
+    return []byte(b.String()), nil
+}
+
+// parse makes a job of every script listed by Read. The jobs share the
+// schedule and environment of the trigger, or have no schedule at all.
+func (s *RunPartsSource) parse(data []byte) *parser.Result {
+    result := &parser.Result{CronJobs: make([]parser.CronJob, 0), Format: parser.SystemFormat}
+    for i, path := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+        if path == "" {
+            continue
+        }
+        job := parser.CronJob{LineNumber: i + 1, Raw: path, User: "root", Command: path, Source: s.dir}
+        if s.trigger != nil {
+            job.Schedule, job.Spec = s.trigger.Schedule, s.trigger.Spec
+            job.Env, job.EnvOrder = s.trigger.Env, s.trigger.EnvOrder
+            job.Description = s.trigger.Description
+            job.Via = fmt.Sprintf("%s:%d", s.trigger.Source, s.trigger.LineNumber)
+        } else {
+            job.Description = "Run by anacron, at a time it picks"
+            job.Via = parser.ViaAnacron
+        }
+        result.CronJobs = append(result.CronJobs, job)
+    }
+    return result
+}
+
+func (s *RunPartsSource) Write(data []byte) error {
+    return fmt.Errorf("%s is a run-parts directory, add or remove scripts in it instead", s.dir)
+}
@@ -0,0 +1,174 @@
+package source
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+    "crontab-tui/parser"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string, mode os.FileMode) {
+    t.Helper()
+    for name, content := range files {
+        path := filepath.Join(root, name)
+        if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(path, []byte(content), mode); err != nil {
+            t.Fatal(err)
+        }
+    }
+}
+
+func TestLoadAllFakeRoot(t *testing.T) {
+    root := t.TempDir()
+    writeTree(t, root, map[string]string{
+        "var/spool/cron/crontabs/alice": "# alice\n*/5 * * * * /home/alice/poll\n",
+        "etc/crontab":                   "SHELL=/bin/sh\n17 * * * * root cd / && run-parts /etc/cron.hourly\n",
+        "etc/cron.d/backup":             "0 3 * * * backup /usr/local/bin/backup\n",
+        "etc/cron.d/backup.dpkg-old":    "0 4 * * * backup /old\n",
+        "etc/cron.d/.placeholder":       "",
+    }, 0644)
+    writeTree(t, root, map[string]string{
+        "etc/cron.daily/logrotate":   "#!/bin/sh\n",
+        "etc/cron.daily/README.txt":  "not run\n",
+    }, 0755)
+    writeTree(t, root, map[string]string{
+        "etc/cron.daily/disabled": "#!/bin/sh\n",
+    }, 0644)
+
+    sources, err := AllSources(root)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(sources) != 4 {
+        t.Fatalf("got %d sources, want 4", len(sources))
+    }
+
+    result, errs := LoadAll(sources)
+    if len(errs) != 0 {
+        t.Fatalf("unexpected errors: %v", errs)
+    }
+    if !result.Aggregated {
+        t.Errorf("result should be marked aggregated")
+    }
+
+    want := []struct {
+        user, command, source string
+        line                  int
+    }{
+        {"alice", "/home/alice/poll", "var/spool/cron/crontabs/alice", 2},
+        {"root", "cd / && run-parts /etc/cron.hourly", "etc/crontab", 2},
+        {"backup", "/usr/local/bin/backup", "etc/cron.d/backup", 1},
+        {"root", filepath.Join(root, "etc/cron.daily/logrotate"), "etc/cron.daily", 1},
+    }
+    if len(result.CronJobs) != len(want) {
+        t.Fatalf("got %d jobs, want %d: %+v", len(result.CronJobs), len(want), result.CronJobs)
+    }
+    for i, w := range want {
+        job := result.CronJobs[i]
+        if job.User != w.user || job.Command != w.command || job.Source != filepath.Join(root, w.source) || job.LineNumber != w.line {
+            t.Errorf("job %d = %s %q from %s:%d, want %s %q from %s:%d",
+                i, job.User, job.Command, job.Source, job.LineNumber, w.user, w.command, w.source, w.line)
+        }
+    }
+
+    filtered := result.Filter(filepath.Join(root, "etc/cron.d/backup"))
+    if len(filtered.CronJobs) != 1 {
+        t.Errorf("filter kept %d jobs, want 1", len(filtered.CronJobs))
+    }
+    if n := len(result.Sources()); n != 4 {
+        t.Errorf("got %d distinct sources, want 4", n)
+    }
+}
+
+func TestLoadAllReportsUnreadable(t *testing.T) {
+    root := t.TempDir()
+    writeTree(t, root, map[string]string{
+        "etc/crontab": "0 0 * * * root /bin/true\n",
+    }, 0644)
+    sources := []Source{NewFileSource(filepath.Join(root, "missing")), NewSystemFileSource(filepath.Join(root, "etc/crontab"))}
+    result, errs := LoadAll(sources)
+    if len(errs) != 1 {
+        t.Fatalf("got %d errors, want 1", len(errs))
+    }
+    if len(result.CronJobs) != 1 {
+        t.Fatalf("readable sources should still be loaded")
+    }
+}
+
+func TestRunPartsSchedule(t *testing.T) {
+    crontab := "17 * * * * root cd / && run-parts --report /etc/cron.hourly\n" +
+        "25 6 * * * root test -x /usr/sbin/anacron || { cd / && run-parts --report /etc/cron.daily; }\n" +
+        "# 47 6 * * 7 root run-parts /etc/cron.weekly\n"
+    scripts := map[string]string{
+        "etc/cron.hourly/rotate": "#!/bin/sh\n",
+        "etc/cron.daily/backup":  "#!/bin/sh\n",
+        "etc/cron.weekly/report": "#!/bin/sh\n",
+    }
+
+    tests := []struct {
+        name    string
+        anacron bool
+        // want maps each script to its schedule and Via, relative to the
+        // root, both empty for anacron.
+        want map[string][2]string
+    }{
+        {"cron", false, map[string][2]string{
+            "etc/cron.hourly/rotate": {"17 * * * *", "etc/crontab:1"},
+            "etc/cron.daily/backup":  {"25 6 * * *", "etc/crontab:2"},
+            "etc/cron.weekly/report": {"", ""},
+        }},
+        {"anacron installed", true, map[string][2]string{
+            "etc/cron.hourly/rotate": {"17 * * * *", "etc/crontab:1"},
+            "etc/cron.daily/backup":  {"", ""},
+            "etc/cron.weekly/report": {"", ""},
+        }},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            root := t.TempDir()
+            writeTree(t, root, map[string]string{"etc/crontab": crontab}, 0644)
+            writeTree(t, root, scripts, 0755)
+            if tt.anacron {
+                writeTree(t, root, map[string]string{"usr/sbin/anacron": ""}, 0755)
+            }
+            sources, err := AllSources(root)
+            if err != nil {
+                t.Fatal(err)
+            }
+            result, errs := LoadAll(sources)
+            if len(errs) != 0 {
+                t.Fatal(errs)
+            }
+
+            seen := 0
+            now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+            for _, job := range result.CronJobs {
+                want, ok := tt.want[strings.TrimPrefix(job.Command, root+"/")]
+                if !ok {
+                    continue
+                }
+                seen++
+                schedule, via := want[0], want[1]
+                if via == "" {
+                    via = parser.ViaAnacron
+                } else {
+                    via = filepath.Join(root, via)
+                }
+                if got := strings.Join(job.Schedule, " "); got != schedule || job.Via != via {
+                    t.Errorf("%s: schedule %q via %q, want %q via %q", job.Command, got, job.Via, schedule, via)
+                }
+                if next := job.NextRun(now); (schedule == "") != next.IsZero() {
+                    t.Errorf("%s: next run %v", job.Command, next)
+                }
+            }
+            if seen != len(tt.want) {
+                t.Errorf("found %d of the %d scripts", seen, len(tt.want))
+            }
+        })
+    }
+}
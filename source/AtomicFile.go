@@ -0,0 +1,115 @@
+package source
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "syscall"
+)
+
+// WriteFileAtomic replaces path with data so that readers see either the
+// old or the new content, never a partial file. It holds an advisory lock
+// on the directory while writing, goes through a fsynced temp file and a
+// rename, and keeps the mode and ownership of the file it replaces.
+func WriteFileAtomic(path string, data []byte) error {
+    unlock, err := lockFor(path)
+    if err != nil {
+        return err
+    }
+    defer unlock()
+    return replaceFile(path, data)
+}
+
+// UpdateFileAtomic is WriteFileAtomic for content derived from the current
+// one: fn gets what path holds, and the lock is kept until what it returns
+// is written, so that no other writer can change the file in between.
+func UpdateFileAtomic(path string, fn func(current []byte) ([]byte, error)) error {
+    unlock, err := lockFor(path)
+    if err != nil {
+        return err
+    }
+    defer unlock()
+    current, err := os.ReadFile(path)
+    if err != nil {
+        return err
+    }
+    data, err := fn(current)
+    if err != nil {
+        return err
+    }
+    return replaceFile(path, data)
+}
+
+func lockFor(path string) (func(), error) {
+    dir := filepath.Dir(path)
+    unlock, err := lockDir(dir)
+    if err != nil {
+        return nil, fmt.Errorf("failed to lock %s: %w", dir, err)
+    }
+    return unlock, nil
+}
+
+// replaceFile does the work of WriteFileAtomic, with the lock held.
+func replaceFile(path string, data []byte) error {
+    dir := filepath.Dir(path)
+    mode := os.FileMode(0644)
+    uid, gid := -1, -1
+    if info, err := os.Stat(path); err == nil {
+        mode = info.Mode().Perm()
+        uid, gid = fileOwner(info)
+    }
+
+    tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+    if err != nil {
+        return err
+    }
+    tmpName := tmp.Name()
+    committed := false
+    defer func() {
+        if !committed {
+            os.Remove(tmpName)
+        }
+    }()
+
+    if _, err := tmp.Write(data); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Chmod(mode); err != nil {
+        tmp.Close()
+        return err
+    }
+    if uid >= 0 && (uid != os.Getuid() || gid != os.Getgid()) {
+        // Only root can give the file away, anyone else ends up owning the
+        // new file which is the best we can do.
+        if err := tmp.Chown(uid, gid); err != nil && os.Getuid() == 0 {
+            tmp.Close()
+            return err
+        }
+    }
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    if err := os.Rename(tmpName, path); err != nil {
+        return err
+    }
+    committed = true
+    return syncDir(dir)
+}
+
+// syncDir makes the rename durable.
+func syncDir(dir string) error {
+    d, err := os.Open(dir)
+    if err != nil {
+        return err
+    }
+    defer d.Close()
+    if err := d.Sync(); err != nil && err != syscall.EINVAL {
+        return err
+    }
+    return nil
+}
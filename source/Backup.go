@@ -0,0 +1,193 @@
+package source
+
+import (
+    "bytes"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+)
+
+// DefaultBackupKeep is how many backups are kept per crontab.
+const DefaultBackupKeep = 20
+
+const backupTimeLayout = "20060102T150405.000000000"
+
+// BackupStore keeps timestamped copies of crontabs taken before they are
+// overwritten, in one directory per crontab.
+type BackupStore struct {
+    Dir  string
+    Keep int
+}
+
+// Backup is one saved copy.
+type Backup struct {
+    Path string
+    Time time.Time
+    Size int64
+}
+
+// DefaultBackupDir is below $XDG_STATE_HOME, never next to the crontab
+// where cron could pick the copies up as jobs.
+func DefaultBackupDir() string {
+    state := os.Getenv("XDG_STATE_HOME")
+    if state == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return filepath.Join(os.TempDir(), "crontab-tui-backups")
+        }
+        state = filepath.Join(home, ".local", "state")
+    }
+    return filepath.Join(state, "crontab-tui", "backups")
+}
+
+func NewBackupStore(dir string) *BackupStore {
+    return &BackupStore{Dir: dir, Keep: DefaultBackupKeep}
+}
+
+// backupKey turns a source name such as "/etc/cron.d/php" or
+// "crontab -u alice" into a directory name.
+func backupKey(name string) string {
+    return strings.Map(func(r rune) rune {
+        switch {
+        case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+            return r
+        }
+        return '_'
+    }, strings.TrimPrefix(name, "/"))
+}
+
+// Save stores data as the newest backup of name and drops the oldest ones
+// beyond Keep.
+func (store *BackupStore) Save(name string, data []byte) error {
+    dir := filepath.Join(store.Dir, backupKey(name))
+    if err := os.MkdirAll(dir, 0700); err != nil {
+        return err
+    }
+    path := filepath.Join(dir, time.Now().UTC().Format(backupTimeLayout))
+    if err := WriteFileAtomic(path, data); err != nil {
+        return err
+    }
+    if err := os.Chmod(path, 0600); err != nil {
+        return err
+    }
+    return store.rotate(name)
+}
+
+// List returns the backups of name, newest first.
+func (store *BackupStore) List(name string) ([]Backup, error) {
+    dir := filepath.Join(store.Dir, backupKey(name))
+    entries, err := os.ReadDir(dir)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    var backups []Backup
+    for _, entry := range entries {
+        t, err := time.Parse(backupTimeLayout, entry.Name())
+        if err != nil || !entry.Type().IsRegular() {
+            continue
+        }
+        info, err := entry.Info()
+        if err != nil {
+            continue
+        }
+        backups = append(backups, Backup{Path: filepath.Join(dir, entry.Name()), Time: t, Size: info.Size()})
+    }
+    sort.Slice(backups, func(i, j int) bool {
+        return backups[i].Time.After(backups[j].Time)
+    })
+    return backups, nil
+}
+
+func (store *BackupStore) rotate(name string) error {
+    if store.Keep <= 0 {
+        return nil
+    }
+    backups, err := store.List(name)
+    if err != nil {
+        return err
+    }
+    for _, backup := range backups[min(store.Keep, len(backups)):] {
+        if err := os.Remove(backup.Path); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (backup Backup) Read() ([]byte, error) {
+    return os.ReadFile(backup.Path)
+}
+
+// Backed is a source whose previous content is saved to a BackupStore
+// before every write.
+type Backed struct {
+    Source
+    Store *BackupStore
+}
+
+// WithBackups wraps src so that each Write first backs up what it
+// replaces.
+func WithBackups(src Source, store *BackupStore) *Backed {
+    return &Backed{Source: src, Store: store}
+}
+
+func (s *Backed) Write(data []byte) error {
+    current, err := s.Source.Read()
+    if err == nil {
+        if err := s.backup(current, data); err != nil {
+            return err
+        }
+    }
+    return s.Source.Write(data)
+}
+
+// Update backs up the content fn replaces while the wrapped source holds
+// its lock.
+func (s *Backed) Update(fn func(current []byte) ([]byte, error)) error {
+    return Update(s.Source, func(current []byte) ([]byte, error) {
+        data, err := fn(current)
+        if err != nil {
+            return nil, err
+        }
+        if err := s.backup(current, data); err != nil {
+            return nil, err
+        }
+        return data, nil
+    })
+}
+
+func (s *Backed) backup(current []byte, data []byte) error {
+    if len(current) == 0 || bytes.Equal(current, data) {
+        return nil
+    }
+    if err := s.Store.Save(s.Source.Name(), current); err != nil {
+        return fmt.Errorf("backup failed, not writing: %w", err)
+    }
+    return nil
+}
+
+// Backups lists the saved copies of this source, newest first.
+func (s *Backed) Backups() ([]Backup, error) {
+    return s.Store.List(s.Source.Name())
+}
+
+// BackupsOf finds the backup wrapper of src below any other wrapper, nil
+// when its writes are not backed up.
+func BackupsOf(src Source) *Backed {
+    for {
+        switch wrapped := src.(type) {
+        case *Backed:
+            return wrapped
+        case readOnlySource:
+            src = wrapped.Source
+        default:
+            return nil
+        }
+    }
+}
@@ -0,0 +1,103 @@
+package source
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+)
+
+func TestWriteFileAtomicKeepsMode(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "crontab")
+    if err := os.WriteFile(path, []byte("old\n"), 0600); err != nil {
+        t.Fatal(err)
+    }
+    if err := WriteFileAtomic(path, []byte("new\n")); err != nil {
+        t.Fatal(err)
+    }
+    data, err := os.ReadFile(path)
+    if err != nil || string(data) != "new\n" {
+        t.Fatalf("content %q, %v", data, err)
+    }
+    info, err := os.Stat(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if info.Mode().Perm() != 0600 {
+        t.Errorf("mode %v, want 0600", info.Mode().Perm())
+    }
+    entries, _ := os.ReadDir(dir)
+    if len(entries) != 1 {
+        t.Errorf("temp files left behind: %v", entries)
+    }
+}
+
+func TestUpdateHoldsLock(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "crontab")
+    if err := os.WriteFile(path, nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    src := NewFileSource(path)
+
+    // Without the lock across read and write some of the appends would
+    // overwrite each other.
+    const writers = 20
+    var wg sync.WaitGroup
+    for i := 0; i < writers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            err := Update(src, func(current []byte) ([]byte, error) {
+                return append(current, "@daily /bin/true\n"...), nil
+            })
+            if err != nil {
+                t.Error(err)
+            }
+        }()
+    }
+    wg.Wait()
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n := strings.Count(string(data), "\n"); n != writers {
+        t.Errorf("%d lines after %d appends", n, writers)
+    }
+}
+
+func TestBackedWriteRotates(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "crontab")
+    if err := os.WriteFile(path, []byte("v0\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    store := &BackupStore{Dir: filepath.Join(dir, "backups"), Keep: 2}
+    src := WithBackups(NewFileSource(path), store)
+
+    for _, content := range []string{"v1\n", "v1\n", "v2\n", "v3\n"} {
+        if err := src.Write([]byte(content)); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    backups, err := src.Backups()
+    if err != nil {
+        t.Fatal(err)
+    }
+    // v0, v1 and v2 were replaced, an unchanged write is not backed up.
+    if len(backups) != 2 {
+        t.Fatalf("got %d backups, want 2", len(backups))
+    }
+    for i, want := range []string{"v2\n", "v1\n"} {
+        data, err := backups[i].Read()
+        if err != nil || string(data) != want {
+            t.Errorf("backup %d = %q, %v, want %q", i, data, err, want)
+        }
+    }
+    if BackupsOf(ReadOnly(src)) != src {
+        t.Errorf("BackupsOf does not see through ReadOnly")
+    }
+}
@@ -0,0 +1,90 @@
+package source
+
+import (
+    "bytes"
+    "fmt"
+    "os/exec"
+    "strings"
+    "crontab-tui/parser"
+)
+
+// DefaultCrontabBinary is the crontab program used when a CommandSource
+// has no Binary set.
+var DefaultCrontabBinary = "crontab"
+
+// CommandSource reads a user's crontab with `crontab -l` and installs it
+// with `crontab -`, letting crontab(1) deal with permissions and spool
+// locations.
+type CommandSource struct {
+    // Binary is the crontab program to run, tests point it at a fake.
+    Binary string
+    // User is passed as -u when set.
+    User string
+}
+
+func NewCommandSource(user string) *CommandSource {
+    return &CommandSource{User: user}
+}
+
+func (s *CommandSource) Name() string {
+    if s.User != "" {
+        return fmt.Sprintf("crontab -u %s", s.User)
+    }
+    return "crontab"
+}
+
+func (s *CommandSource) Path() string {
+    return ""
+}
+
+func (s *CommandSource) Format() parser.Format {
+    return parser.UserFormat
+}
+
+func (s *CommandSource) binary() string {
+    if s.Binary != "" {
+        return s.Binary
+    }
+    return DefaultCrontabBinary
+}
+
+func (s *CommandSource) args(action string) []string {
+    if s.User != "" {
+        return []string{"-u", s.User, action}
+    }
+    return []string{action}
+}
+
+func (s *CommandSource) Read() ([]byte, error) {
+    cmd := exec.Command(s.binary(), s.args("-l")...)
+    var stdout, stderr bytes.Buffer
+    cmd.Stdout = &stdout
+    cmd.Stderr = &stderr
+    if err := cmd.Run(); err != nil {
+        // A user without a crontab is not an error, it is an empty one.
+        if strings.Contains(stderr.String(), "no crontab for") {
+            return []byte{}, nil
+        }
+        return nil, commandError("read", err, stderr.String())
+    }
+    return stdout.Bytes(), nil
+}
+
+func (s *CommandSource) Write(data []byte) error {
+    cmd := exec.Command(s.binary(), s.args("-")...)
+    var stderr bytes.Buffer
+    cmd.Stdin = bytes.NewReader(data)
+    cmd.Stderr = &stderr
+    if err := cmd.Run(); err != nil {
+        return commandError("install", err, stderr.String())
+    }
+    return nil
+}
+
+func commandError(action string, err error, stderr string) error {
+    stderr = strings.TrimSpace(stderr)
+    if stderr == "" {
+        return fmt.Errorf("failed to %s crontab: %w", action, err)
+    }
+    return fmt.Errorf("failed to %s crontab: %s", action, stderr)
+}
@@ -0,0 +1,104 @@
+package source
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+// fakeCrontab writes a stand-in for crontab(1) that keeps the installed
+// crontab in a file next to it and logs its arguments.
+func fakeCrontab(t *testing.T) (*CommandSource, string) {
+    t.Helper()
+    dir := t.TempDir()
+    script := `#!/bin/sh
+dir=$(dirname "$0")
+echo "$@" >> "$dir/args"
+for last; do :; done
+case "$last" in
+-l)
+    if [ ! -f "$dir/installed" ]; then
+        echo "no crontab for tester" >&2
+        exit 1
+    fi
+    cat "$dir/installed"
+    ;;
+-)
+    input=$(cat)
+    if echo "$input" | grep -q "^bad"; then
+        echo "errors in crontab file, can't install." >&2
+        exit 1
+    fi
+    printf '%s\n' "$input" > "$dir/installed"
+    ;;
+esac
+`
+    bin := filepath.Join(dir, "crontab")
+    if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+        t.Fatal(err)
+    }
+    return &CommandSource{Binary: bin}, dir
+}
+
+func TestCommandSourceNoCrontab(t *testing.T) {
+    src, _ := fakeCrontab(t)
+    data, err := src.Read()
+    if err != nil {
+        t.Fatalf("Read: %v", err)
+    }
+    if len(data) != 0 {
+        t.Fatalf("expected an empty crontab, got %q", data)
+    }
+}
+
+func TestCommandSourceRoundTrip(t *testing.T) {
+    src, dir := fakeCrontab(t)
+    src.User = "alice"
+    content := "MAILTO=alice\n*/5 * * * * /bin/true\n"
+    if err := src.Write([]byte(content)); err != nil {
+        t.Fatalf("Write: %v", err)
+    }
+    data, err := src.Read()
+    if err != nil {
+        t.Fatalf("Read: %v", err)
+    }
+    if string(data) != content {
+        t.Fatalf("got %q, want %q", data, content)
+    }
+
+    args, _ := os.ReadFile(filepath.Join(dir, "args"))
+    if want := "-u alice -\n-u alice -l\n"; string(args) != want {
+        t.Fatalf("crontab called with %q, want %q", args, want)
+    }
+}
+
+func TestCommandSourceInstallError(t *testing.T) {
+    src, _ := fakeCrontab(t)
+    err := src.Write([]byte("bad line\n"))
+    if err == nil || !strings.Contains(err.Error(), "errors in crontab file") {
+        t.Fatalf("expected crontab's error message, got %v", err)
+    }
+}
+
+func TestWatchCommandSourceInterval(t *testing.T) {
+    src, dir := fakeCrontab(t)
+    defer func(interval time.Duration) { CommandPollInterval = interval }(CommandPollInterval)
+    CommandPollInterval = time.Hour
+
+    w, err := Watch(context.Background(), ReadOnly(src), 10*time.Millisecond, func() {})
+    if err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(100 * time.Millisecond)
+    w.Stop()
+    args, err := os.ReadFile(filepath.Join(dir, "args"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n := strings.Count(string(args), "\n"); n != 1 {
+        t.Errorf("crontab ran %d times, want once", n)
+    }
+}
@@ -0,0 +1,52 @@
+package source
+
+import (
+    "os"
+    "crontab-tui/parser"
+)
+
+// FileSource reads and writes a plain crontab file.
+type FileSource struct {
+    path   string
+    format parser.Format
+    owner  string
+}
+
+func NewFileSource(path string) *FileSource {
+    return &FileSource{path: path, format: parser.UserFormat}
+}
+
+// NewSystemFileSource is for files with a user column such as /etc/crontab.
+func NewSystemFileSource(path string) *FileSource {
+    return &FileSource{path: path, format: parser.SystemFormat}
+}
+
+// NewSpoolFileSource is for a per-user file in the cron spool, whose jobs
+// run as owner.
+func NewSpoolFileSource(path string, owner string) *FileSource {
+    return &FileSource{path: path, format: parser.UserFormat, owner: owner}
+}
+
+func (s *FileSource) Name() string {
+    return s.path
+}
+
+func (s *FileSource) Path() string {
+    return s.path
+}
+
+func (s *FileSource) Format() parser.Format {
+    return s.format
+}
+
+func (s *FileSource) Read() ([]byte, error) {
+    return os.ReadFile(s.path)
+}
+
+func (s *FileSource) Write(data []byte) error {
+    return WriteFileAtomic(s.path, data)
+}
+
+func (s *FileSource) Update(fn func(current []byte) ([]byte, error)) error {
+    return UpdateFileAtomic(s.path, fn)
+}
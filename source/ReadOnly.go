@@ -0,0 +1,28 @@
+package source
+
+import (
+    "errors"
+)
+
+var ErrReadOnly = errors.New("read-only mode, changes are disabled")
+
+type readOnlySource struct {
+    Source
+}
+
+// ReadOnly wraps src so that every Write fails with ErrReadOnly.
+func ReadOnly(src Source) Source {
+    return readOnlySource{src}
+}
+
+func (s readOnlySource) Name() string {
+    return s.Source.Name() + " [read-only]"
+}
+
+func (s readOnlySource) Write(data []byte) error {
+    return ErrReadOnly
+}
+
+func (s readOnlySource) Update(fn func(current []byte) ([]byte, error)) error {
+    return ErrReadOnly
+}
@@ -0,0 +1,42 @@
+package source
+
+import (
+    "crontab-tui/parser"
+)
+
+// Source is where a crontab is read from and installed to.
+type Source interface {
+    // Name describes the source for titles and messages.
+    Name() string
+    Read() ([]byte, error)
+    Write(data []byte) error
+    // Path is the file backing the source, used to watch it for changes.
+    // It is empty when there is no file we can watch.
+    Path() string
+    // Format is the line format, system crontabs have a user column.
+    Format() parser.Format
+}
+
+// Updater is a Source that can keep its lock from reading the current
+// content until the new one is written.
+type Updater interface {
+    Update(fn func(current []byte) ([]byte, error)) error
+}
+
+// Update passes the content of src to fn and writes what fn returns, all
+// under the source's lock when it has one. Sources without a lock, like
+// crontab(1), are read and then written. Nothing is written when fn fails.
+func Update(src Source, fn func(current []byte) ([]byte, error)) error {
+    if u, ok := src.(Updater); ok {
+        return u.Update(fn)
+    }
+    current, err := src.Read()
+    if err != nil {
+        return err
+    }
+    data, err := fn(current)
+    if err != nil {
+        return err
+    }
+    return src.Write(data)
+}
@@ -0,0 +1,77 @@
+package source
+
+import (
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+const (
+    SystemCrontab = "/etc/crontab"
+    SystemCronDir = "/etc/cron.d"
+)
+
+// SystemSources returns /etc/crontab followed by the files in /etc/cron.d,
+// both looked up below root ("/" on a live system).
+func SystemSources(root string) ([]Source, error) {
+    sources := []Source{}
+    main := filepath.Join(root, SystemCrontab)
+    if _, err := os.Stat(main); err == nil {
+        sources = append(sources, NewSystemFileSource(main))
+    }
+
+    names, err := cronDirFiles(filepath.Join(root, SystemCronDir))
+    if err != nil {
+        return nil, err
+    }
+    for _, name := range names {
+        sources = append(sources, NewSystemFileSource(filepath.Join(root, SystemCronDir, name)))
+    }
+    return sources, nil
+}
+
+// IsSystemPath reports whether path is a file cron reads in the system
+// format, /etc/crontab or a file directly in /etc/cron.d.
+func IsSystemPath(path string) bool {
+    abs, err := filepath.Abs(path)
+    if err != nil {
+        return false
+    }
+    return abs == SystemCrontab || filepath.Dir(abs) == SystemCronDir
+}
+
+// cronDirFiles lists the regular files cron reads from a cron.d style
+// directory. Like Debian's cron it skips hidden files and editor or
+// package manager leftovers.
+func cronDirFiles(dir string) ([]string, error) {
+    entries, err := os.ReadDir(dir)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    names := []string{}
+    for _, entry := range entries {
+        name := entry.Name()
+        if !entry.Type().IsRegular() || ignoredCronFile(name) {
+            continue
+        }
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names, nil
+}
+
+func ignoredCronFile(name string) bool {
+    if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+        return true
+    }
+    for _, suffix := range []string{".dpkg-old", ".dpkg-new", ".dpkg-dist", ".rpmnew", ".rpmsave", ".swp"} {
+        if strings.HasSuffix(name, suffix) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,95 @@
+package source
+
+import (
+    "bytes"
+    "context"
+    "time"
+    "crontab-tui/parser"
+)
+
+// Watcher is returned by Watch, Stop ends the watch.
+type Watcher interface {
+    Stop()
+}
+
+// CommandPollInterval is the shortest interval a crontab(1) backed source
+// is polled at. Every read runs crontab -l, and a change made meanwhile is
+// still caught when saving.
+var CommandPollInterval = 30 * time.Second
+
+// Watch calls callback when the source changes. File backed sources use
+// parser.WatchCronFile, the others are polled with Read.
+func Watch(ctx context.Context, src Source, interval time.Duration, callback func()) (Watcher, error) {
+    if path := src.Path(); path != "" {
+        return parser.WatchCronFileContext(ctx, path, interval, callback)
+    }
+    if _, ok := unwrap(src).(*CommandSource); ok {
+        interval = max(interval, CommandPollInterval)
+    }
+    return pollSource(ctx, src, interval, callback), nil
+}
+
+// WatchAll polls every source and calls callback when any of them changed.
+func WatchAll(ctx context.Context, sources []Source, interval time.Duration, callback func()) Watcher {
+    return pollSource(ctx, &multiSource{sources}, interval, callback)
+}
+
+// multiSource concatenates the content of several sources, only to tell
+// when one of them changed.
+type multiSource struct {
+    sources []Source
+}
+
+func (m *multiSource) Read() ([]byte, error) {
+    var all []byte
+    for _, src := range m.sources {
+        data, _ := src.Read()
+        all = append(all, []byte(src.Name())...)
+        all = append(all, 0)
+        all = append(all, data...)
+    }
+    return all, nil
+}
+
+type pollWatcher struct {
+    cancel context.CancelFunc
+    done   chan struct{}
+}
+
+type reader interface {
+    Read() ([]byte, error)
+}
+
+func pollSource(ctx context.Context, src reader, interval time.Duration, callback func()) *pollWatcher {
+    if interval <= 0 {
+        interval = time.Second
+    }
+    ctx, cancel := context.WithCancel(ctx)
+    w := &pollWatcher{cancel: cancel, done: make(chan struct{})}
+    last, _ := src.Read()
+
+    go func() {
+        defer close(w.done)
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+                data, err := src.Read()
+                if err != nil || bytes.Equal(data, last) {
+                    continue
+                }
+                last = data
+                callback()
+            }
+        }
+    }()
+    return w
+}
+
+func (w *pollWatcher) Stop() {
+    w.cancel()
+    <-w.done
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package source
+
+import (
+    "os"
+    "syscall"
+)
+
+// fileOwner returns the owner and group of the file info describes.
+func fileOwner(info os.FileInfo) (int, int) {
+    if st, ok := info.Sys().(*syscall.Stat_t); ok {
+        return int(st.Uid), int(st.Gid)
+    }
+    return -1, -1
+}
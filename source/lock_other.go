@@ -0,0 +1,7 @@
+//go:build !unix
+
+package source
+
+func lockDir(dir string) (func(), error) {
+    return func() {}, nil
+}
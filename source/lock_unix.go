@@ -0,0 +1,43 @@
+//go:build unix
+
+package source
+
+import (
+    "errors"
+    "os"
+    "syscall"
+    "time"
+)
+
+// LockTimeout is how long a write waits for another writer to finish.
+var LockTimeout = 5 * time.Second
+
+// lockDir takes an exclusive flock on dir. Locking the directory rather
+// than the file survives the file being replaced by a rename and does not
+// leave lock files behind in places like /etc/cron.d.
+func lockDir(dir string) (func(), error) {
+    d, err := os.Open(dir)
+    if err != nil {
+        return nil, err
+    }
+    fd := int(d.Fd())
+    deadline := time.Now().Add(LockTimeout)
+    for {
+        err := syscall.Flock(fd, syscall.LOCK_EX|syscall.LOCK_NB)
+        if err == nil {
+            break
+        }
+        if err != syscall.EWOULDBLOCK || time.Now().After(deadline) {
+            d.Close()
+            if err == syscall.EWOULDBLOCK {
+                return nil, errors.New("locked by another writer")
+            }
+            return nil, err
+        }
+        time.Sleep(50 * time.Millisecond)
+    }
+    return func() {
+        syscall.Flock(fd, syscall.LOCK_UN)
+        d.Close()
+    }, nil
+}
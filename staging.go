@@ -0,0 +1,131 @@
+package main
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "fmt"
+    "strings"
+    "crontab-tui/diff"
+    "crontab-tui/parser"
+    "crontab-tui/source"
+)
+
+// staging is set in staged mode, where changes collect in memory until
+// they are saved in one write.
+var staging bool
+
+// stage holds the pending changes, nil when there are none.
+var stage *Stage
+
+// Stage is a crontab with changes not written yet.
+type Stage struct {
+    Src source.Source
+    // Base is the content the changes were made on, Data the content with
+    // the changes applied.
+    Base    []byte
+    Data    []byte
+    Actions []string
+}
+
+// stageChange is mutateCrontab in staged mode.
+func stageChange(src source.Source, action string, fn func(doc *parser.Document) error) error {
+    if stage != nil && stage.Src != src {
+        return fmt.Errorf("%s has staged changes, save or discard them first", stage.Src.Name())
+    }
+    if stage == nil {
+        base, ok := baselines[src]
+        if !ok {
+            data, err := src.Read()
+            if err != nil {
+                return err
+            }
+            setBaseline(src, data)
+            base = baselines[src]
+        }
+        stage = &Stage{Src: src, Base: base.data, Data: base.data}
+    }
+    doc := parser.ParseDocumentFormat(stage.Data, src.Format())
+    if err := fn(doc); err != nil {
+        return err
+    }
+    before := stage.Data
+    stage.Data = doc.Bytes()
+    stage.Actions = append(stage.Actions, action)
+    undoStack.pushStaged(src, action, before, stage.Data)
+    return nil
+}
+
+func (s *Stage) undo(entry undoEntry) error {
+    if s == nil || s.Src != entry.src || !bytes.Equal(s.Data, entry.after) {
+        return fmt.Errorf("the staged changes were saved or discarded, cannot undo \"%s\"", entry.action)
+    }
+    s.Data = entry.before
+    s.Actions = s.Actions[:len(s.Actions)-1]
+    return nil
+}
+
+// Len is the number of staged changes.
+func (s *Stage) Len() int {
+    if s == nil {
+        return 0
+    }
+    return len(s.Actions)
+}
+
+// Diff is the unified diff of the staged changes against what is on disk
+// now.
+func (s *Stage) Diff() (string, error) {
+    current, err := s.Src.Read()
+    if err != nil {
+        return "", err
+    }
+    return diff.Unified(s.Src.Name()+" (on disk)", s.Src.Name()+" (staged)", current, s.Data, 3), nil
+}
+
+// validateDocument refuses content with lines cron would not accept.
+func validateDocument(data []byte, format parser.Format) error {
+    var problems []string
+    for _, line := range parser.ParseDocumentFormat(data, format).Lines {
+        if line.Kind == parser.LineInvalid {
+            problems = append(problems, fmt.Sprintf("line %d: %v", line.Number, line.Err))
+        }
+    }
+    if len(problems) > 0 {
+        return fmt.Errorf("not saving, %s", strings.Join(problems, "; "))
+    }
+    return nil
+}
+
+// SaveStage validates the staged document and writes it in one go. It
+// returns a *ConflictError when the crontab changed since the first
+// staged change.
+func SaveStage() error {
+    if stage.Len() == 0 {
+        return fmt.Errorf("nothing staged")
+    }
+    if err := validateDocument(stage.Data, stage.Src.Format()); err != nil {
+        return err
+    }
+    action := fmt.Sprintf("save %d staged change(s)", stage.Len())
+    err := updateCrontab(stage.Src, action, func(current []byte) ([]byte, error) {
+        if sha256.Sum256(current) != sha256.Sum256(stage.Base) {
+            return nil, &ConflictError{Src: stage.Src, Action: action, Base: stage.Base, Mine: stage.Data, Theirs: current}
+        }
+        return stage.Data, nil
+    })
+    if err != nil {
+        return err
+    }
+    clearStage()
+    return nil
+}
+
+// clearStage drops the staged changes, after saving or to discard them.
+func clearStage() {
+    stage = nil
+    pendingImport = nil
+    if importStaging {
+        staging, importStaging = false, false
+    }
+    undoStack.dropStaged()
+}
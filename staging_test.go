@@ -0,0 +1,168 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "crontab-tui/parser"
+    "crontab-tui/source"
+)
+
+// countingSource counts the writes that reach the crontab.
+type countingSource struct {
+    source.Source
+    writes int
+}
+
+func (s *countingSource) Write(data []byte) error {
+    s.writes++
+    return s.Source.Write(data)
+}
+
+const stagedContent = "# jobs\n@hourly /bin/true\n0 3 * * * /bin/sh -c backup\n@weekly /bin/sh -c old\n"
+
+// startStaging loads a crontab holding stagedContent in staged mode and
+// returns it with its jobs.
+func startStaging(t *testing.T) (*countingSource, string, []parser.CronJob) {
+    t.Helper()
+    undoStack = &UndoStack{}
+    baselines = map[source.Source]baseline{}
+    staging, stage = true, nil
+    t.Cleanup(func() {
+        staging, stage = false, nil
+    })
+    path := filepath.Join(t.TempDir(), "crontab")
+    if err := os.WriteFile(path, []byte(stagedContent), 0644); err != nil {
+        t.Fatal(err)
+    }
+    src := &countingSource{Source: source.NewFileSource(path)}
+    setBaseline(src, []byte(stagedContent))
+    return src, path, parser.ParseDocument([]byte(stagedContent)).Result().CronJobs
+}
+
+func readFile(t *testing.T, path string) string {
+    t.Helper()
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return string(data)
+}
+
+func TestStageAndSave(t *testing.T) {
+    src, path, jobs := startStaging(t)
+    edits := []func() error{
+        func() error { return DeleteCrontabJob(src, &jobs[2]) },
+        func() error { return ToggleCrontabJob(src, &jobs[1]) },
+        func() error { return ReplaceCrontabJob(src, &jobs[0], []string{"@hourly"}, "", "/bin/false") },
+        func() error { return AppendCrontabJob(src, []string{"@daily"}, "", "/bin/date") },
+    }
+    for i, edit := range edits {
+        if err := edit(); err != nil {
+            t.Fatalf("edit %d: %v", i+1, err)
+        }
+        if stage.Len() != i+1 {
+            t.Errorf("%d staged changes, want %d", stage.Len(), i+1)
+        }
+        if got := readFile(t, path); got != stagedContent {
+            t.Fatalf("edit %d reached the crontab: %q", i+1, got)
+        }
+    }
+    want := "# jobs\n@hourly /bin/false\n" + parser.DisableLine("0 3 * * * /bin/sh -c backup") + "\n@daily /bin/date\n"
+    if string(stage.Data) != want {
+        t.Errorf("staged %q, want %q", stage.Data, want)
+    }
+
+    if err := SaveStage(); err != nil {
+        t.Fatal(err)
+    }
+    if got := readFile(t, path); got != want {
+        t.Errorf("saved %q, want %q", got, want)
+    }
+    if src.writes != 1 {
+        t.Errorf("%d writes, want 1", src.writes)
+    }
+    if stage != nil || undoStack.Len() != 1 {
+        t.Errorf("stage %v and %d undo entries left, want none and the save", stage, undoStack.Len())
+    }
+    if _, err := undoStack.Undo(); err != nil {
+        t.Fatal(err)
+    }
+    if got := readFile(t, path); got != stagedContent {
+        t.Errorf("crontab is %q after undoing the save, want %q", got, stagedContent)
+    }
+}
+
+func TestSaveStageInvalid(t *testing.T) {
+    src, path, jobs := startStaging(t)
+    if err := ReplaceCrontabJob(src, &jobs[0], []string{"61", "*", "*", "*", "*"}, "", "/bin/true"); err != nil {
+        t.Fatal(err)
+    }
+    if err := SaveStage(); err == nil || !strings.Contains(err.Error(), "line 2") {
+        t.Errorf("got %v, want the invalid line 2 refused", err)
+    }
+    if src.writes != 0 || readFile(t, path) != stagedContent {
+        t.Error("an invalid stage was written")
+    }
+    if stage.Len() != 1 {
+        t.Errorf("%d staged changes left, want 1", stage.Len())
+    }
+}
+
+func TestSaveStageConflict(t *testing.T) {
+    src, path, _ := startStaging(t)
+    if err := AppendCrontabJob(src, []string{"@daily"}, "", "/bin/date"); err != nil {
+        t.Fatal(err)
+    }
+    const external = "# edited elsewhere\n"
+    if err := os.WriteFile(path, []byte(external), 0644); err != nil {
+        t.Fatal(err)
+    }
+    err := SaveStage()
+    conflict, ok := err.(*ConflictError)
+    if !ok {
+        t.Fatalf("got %v, want a *ConflictError", err)
+    }
+    if string(conflict.Base) != stagedContent || string(conflict.Mine) != string(stage.Data) || string(conflict.Theirs) != external {
+        t.Errorf("conflict base %q, mine %q, theirs %q", conflict.Base, conflict.Mine, conflict.Theirs)
+    }
+    if src.writes != 0 || readFile(t, path) != external {
+        t.Error("the stage was written over the external change")
+    }
+}
+
+func TestDiscardStage(t *testing.T) {
+    src, path, jobs := startStaging(t)
+    if err := ToggleCrontabJob(src, &jobs[0]); err != nil {
+        t.Fatal(err)
+    }
+    if err := AppendCrontabJob(src, []string{"@daily"}, "", "/bin/date"); err != nil {
+        t.Fatal(err)
+    }
+    clearStage()
+    if stage != nil || undoStack.Len() != 0 {
+        t.Errorf("stage %v and %d undo entries left, want none", stage, undoStack.Len())
+    }
+    if src.writes != 0 || readFile(t, path) != stagedContent {
+        t.Error("discarding wrote to the crontab")
+    }
+}
+
+func TestDropStaged(t *testing.T) {
+    stack := &UndoStack{}
+    stack.push(nil, "add job", nil, nil)
+    stack.pushStaged(nil, "edit line 2", nil, nil)
+    stack.push(nil, "delete line 3", nil, nil)
+    stack.pushStaged(nil, "disable line 4", nil, nil)
+    stack.dropStaged()
+
+    var got []string
+    for _, entry := range stack.entries {
+        got = append(got, entry.action)
+    }
+    if want := []string{"add job", "delete line 3"}; !reflect.DeepEqual(got, want) {
+        t.Errorf("kept %q, want %q", got, want)
+    }
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+    "github.com/jroimartin/gocui"
+    "fmt"
+    "crontab-tui/source"
+)
+
+// BackupsPanel lists the saved copies of the current crontab, newest
+// first, for picking one to restore.
+type BackupsPanel struct {
+    ViewName     string
+    viewPosition ViewPosition
+    Backups      []source.Backup
+}
+
+func NewBackupsPanel() (*BackupsPanel, error) {
+    backupsPanel := BackupsPanel{
+        ViewName: "backups",
+        viewPosition: ViewPosition {
+            x0: Position{0.15, 0},
+            y0: Position{0.15, 0},
+            x1: Position{0.85, 2},
+            y1: Position{0.75, 2},
+        },
+    }
+    return &backupsPanel, nil
+}
+
+func (backupsPanel *BackupsPanel) DrawView(g *gocui.Gui, name string, backups []source.Backup) error {
+    backupsPanel.Backups = backups
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := backupsPanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(backupsPanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    v.Title = fmt.Sprintf(" Backups of %s ── Enter: restore  Esc: close ", name)
+    v.SelFgColor = gocui.ColorBlue
+    v.SelBgColor = gocui.ColorGreen
+    v.Highlight = true
+    v.Clear()
+    v.SetCursor(0, 0)
+    v.SetOrigin(0, 0)
+    for _, backup := range backups {
+        fmt.Fprintf(v, "%s  %8d bytes\n", backup.Time.Local().Format("2006-01-02 15:04:05"), backup.Size)
+    }
+    if len(backups) == 0 {
+        fmt.Fprintln(v, "No backups yet, one is taken before every change.")
+    }
+    if _, err := g.SetCurrentView(backupsPanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
+
+// Selected returns the backup under the cursor.
+func (backupsPanel *BackupsPanel) Selected(g *gocui.Gui) (source.Backup, bool) {
+    v, err := g.View(backupsPanel.ViewName)
+    if err != nil {
+        return source.Backup{}, false
+    }
+    _, oy := v.Origin()
+    _, cy := v.Cursor()
+    idx := oy + cy
+    if idx < 0 || idx >= len(backupsPanel.Backups) {
+        return source.Backup{}, false
+    }
+    return backupsPanel.Backups[idx], true
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+    "github.com/jroimartin/gocui"
+    "fmt"
+)
+
+type ConfirmPanel struct {
+    ViewName    string
+    viewPosition ViewPosition
+}
+
+func NewConfirmPanel() (*ConfirmPanel, error) {
+    confirmPanel := ConfirmPanel{
+        ViewName: "confirm",
+        viewPosition: ViewPosition {
+            x0: Position{0.1, 0},
+            y0: Position{0.3, 0},
+            x1: Position{0.9, 2},
+            y1: Position{0.6, 2},
+        },
+    }
+    return &confirmPanel, nil
+}
+
+func (confirmPanel *ConfirmPanel) DrawView(g *gocui.Gui, title string, message string) error {
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := confirmPanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(confirmPanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    v.Title = " " + title + " "
+    v.Wrap = true
+    v.Clear()
+    fmt.Fprintln(v, message)
+    fmt.Fprintln(v, "")
+    fmt.Fprintln(v, "y/Enter: confirm\tn/Esc: cancel")
+    if _, err := g.SetCurrentView(confirmPanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,155 @@
+package ui
+
+import (
+    "github.com/jroimartin/gocui"
+    "fmt"
+    "strings"
+    "crontab-tui/diff"
+)
+
+const conflictHelp = "1: Mine  2: Theirs  3: Both  j/k: Next/Prev conflict\nEnter: Save merge  m: Keep all mine  t/Esc: Take theirs"
+
+// ConflictPanel shows a three-way merge between a change and what was
+// written to the crontab in the meantime, and lets the user pick a side
+// for each conflicting hunk.
+type ConflictPanel struct {
+    ViewName     string
+    viewPosition ViewPosition
+    Hunks        []diff.Hunk
+    // Selected is the index in Hunks of the conflict being decided.
+    Selected     int
+    title        string
+}
+
+func NewConflictPanel() (*ConflictPanel, error) {
+    conflictPanel := ConflictPanel{
+        ViewName: "conflict",
+        viewPosition: ViewPosition {
+            x0: Position{0.05, 0},
+            y0: Position{0.05, 0},
+            x1: Position{0.95, 2},
+            y1: Position{0.9, 2},
+        },
+    }
+    return &conflictPanel, nil
+}
+
+func (conflictPanel *ConflictPanel) DrawView(g *gocui.Gui, title string, hunks []diff.Hunk) error {
+    conflictPanel.Hunks = hunks
+    conflictPanel.title = title
+    conflictPanel.Selected = conflictPanel.next(-1, 1)
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := conflictPanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(conflictPanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    conflictPanel.draw(v)
+    if _, err := g.SetCurrentView(conflictPanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
+
+// Move selects the next (d > 0) or previous conflict.
+func (conflictPanel *ConflictPanel) Move(g *gocui.Gui, d int) error {
+    if next := conflictPanel.next(conflictPanel.Selected, d); next >= 0 {
+        conflictPanel.Selected = next
+    }
+    return conflictPanel.redraw(g)
+}
+
+// Choose decides the selected conflict and moves on to the next one.
+func (conflictPanel *ConflictPanel) Choose(g *gocui.Gui, choice diff.Choice) error {
+    if conflictPanel.Selected < 0 {
+        return nil
+    }
+    conflictPanel.Hunks[conflictPanel.Selected].Choice = choice
+    return conflictPanel.Move(g, 1)
+}
+
+func (conflictPanel *ConflictPanel) next(from int, d int) int {
+    for i := from + d; i >= 0 && i < len(conflictPanel.Hunks); i += d {
+        if conflictPanel.Hunks[i].Conflict {
+            return i
+        }
+    }
+    return -1
+}
+
+func (conflictPanel *ConflictPanel) redraw(g *gocui.Gui) error {
+    v, err := g.View(conflictPanel.ViewName)
+    if err != nil {
+        return err
+    }
+    conflictPanel.draw(v)
+    return nil
+}
+
+func (conflictPanel *ConflictPanel) draw(v *gocui.View) {
+    conflicts := diff.Conflicts(conflictPanel.Hunks)
+    v.Title = fmt.Sprintf(" %s ── %d conflict(s) ", conflictPanel.title, conflicts)
+    v.Wrap = false
+    v.Clear()
+    fmt.Fprintln(v, conflictHelp)
+    fmt.Fprintln(v, "")
+    row, selectedRow := 3, 0
+    number := 0
+    for i, h := range conflictPanel.Hunks {
+        switch {
+        case h.Conflict:
+            number++
+            marker := "  "
+            header := fmt.Sprintf("<<<<<<< conflict %d/%d: %s", number, conflicts, choiceName(h.Choice))
+            if i == conflictPanel.Selected {
+                marker = "▶ "
+                selectedRow = row
+                header = "\033[7m" + header + "\033[0m"
+            }
+            fmt.Fprintf(v, "%s\033[33m%s\033[0m\n", marker, header)
+            row++
+            row += printLines(v, "  1 ", "\033[36m", h.Mine)
+            fmt.Fprintln(v, "  \033[33m=======\033[0m")
+            row++
+            row += printLines(v, "  2 ", "\033[35m", h.Theirs)
+            fmt.Fprintln(v, "  \033[33m>>>>>>>\033[0m")
+            row++
+        case h.Changed():
+            row += printLines(v, "  - ", "\033[31m", h.Base)
+            row += printLines(v, "  + ", "\033[32m", h.Lines())
+        default:
+            row += printLines(v, "    ", "", h.Base)
+        }
+    }
+    // Keep the selected conflict in view.
+    _, height := v.Size()
+    origin := 0
+    if selectedRow > height/2 {
+        origin = selectedRow - height/3
+    }
+    v.SetOrigin(0, origin)
+}
+
+func printLines(v *gocui.View, prefix string, color string, lines []string) int {
+    for _, line := range lines {
+        line = strings.TrimRight(line, "\r\n")
+        if color == "" {
+            fmt.Fprintf(v, "%s%s\n", prefix, line)
+        } else {
+            fmt.Fprintf(v, "%s%s%s\033[0m\n", color, prefix, line)
+        }
+    }
+    return len(lines)
+}
+
+func choiceName(choice diff.Choice) string {
+    switch choice {
+    case diff.ChooseMine:
+        return "keep mine (1)"
+    case diff.ChooseTheirs:
+        return "take theirs (2)"
+    case diff.ChooseBoth:
+        return "keep both (3)"
+    }
+    return "undecided"
+}
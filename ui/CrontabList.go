@@ -4,12 +4,18 @@ import (
     "github.com/jroimartin/gocui"
     "crontab-tui/parser"
     "fmt"
+    "time"
 )
 
 type CrontabListPanel struct {
     ViewName        string
     viewPosition    ViewPosition
     CrontabList     *parser.Result
+    SourceName      string
+    // Filter limits an aggregated list to the jobs of one source.
+    Filter          string
+    // Location is the zone next runs are shown in, nil for the system's.
+    Location        *time.Location
 }
 
 func NewCrontabListPanel() (*CrontabListPanel, error) {
@@ -19,7 +25,7 @@ func NewCrontabListPanel() (*CrontabListPanel, error) {
             x0: Position{0.0, 0},
             y0: Position{0.0, 0},
             x1: Position{1.0, 1},
-            y1: Position{0.75, 1},
+            y1: Position{0.55, 1},
         },
         CrontabList: nil,
     }
@@ -37,13 +43,78 @@ func (crontabPanel *CrontabListPanel) DrawView(g *gocui.Gui) error {
         v.SelBgColor = gocui.ColorGreen
         v.Highlight = true
         if crontabPanel.CrontabList != nil {
-            crontabPanel.CrontabList.Draw(v)
+            crontabPanel.Visible().DrawAt(v, now(crontabPanel.Location))
         }
-        v.Title = " Crontab List"
+        v.Title = crontabPanel.title()
     }
     return nil
 }
 
+// The title doubles as the column header. It is drawn one column to the
+// right of the view content, hence the narrower first column.
+func (crontabPanel *CrontabListPanel) title() string {
+    columns := fmt.Sprintf("%-19s %-16s ", "SCHEDULE", "NEXT RUN")
+    list := crontabPanel.CrontabList
+    if list != nil && (list.Format == parser.SystemFormat || list.Aggregated) {
+        columns += fmt.Sprintf("%-10s ", "USER")
+    }
+    if list != nil && list.Aggregated {
+        columns += fmt.Sprintf("%-24s ", "SOURCE")
+    }
+    invalid := ""
+    if list != nil {
+        if n := crontabPanel.Visible().Invalid(); n > 0 {
+            invalid = fmt.Sprintf("─── %d invalid, n: next ", n)
+        }
+    }
+    zone := ""
+    if crontabPanel.Location != nil {
+        zone = fmt.Sprintf("─── times in %s ", crontabPanel.Location)
+    }
+    return fmt.Sprintf("%sCOMMAND ─── %s %s%s", columns, crontabPanel.SourceName, zone, invalid)
+}
+
+// Visible returns the jobs currently listed, in display order.
+func (crontabPanel *CrontabListPanel) Visible() *parser.Result {
+    if crontabPanel.CrontabList == nil {
+        return nil
+    }
+    return crontabPanel.CrontabList.Filter(crontabPanel.Filter)
+}
+
+// Refresh redraws the list content, e.g. after the file changed or to
+// update the next run column.
+func (crontabPanel *CrontabListPanel) Refresh(g *gocui.Gui) error {
+    v, err := g.View(crontabPanel.ViewName)
+    if err != nil {
+        return err
+    }
+    v.Clear()
+    v.Title = crontabPanel.title()
+    if crontabPanel.CrontabList != nil {
+        crontabPanel.Visible().DrawAt(v, now(crontabPanel.Location))
+    }
+    return nil
+}
+
+// Select moves the cursor to the job at index in the visible list,
+// scrolling it into view.
+func (crontabPanel *CrontabListPanel) Select(g *gocui.Gui, index int) error {
+    v, err := g.View(crontabPanel.ViewName)
+    if err != nil {
+        return err
+    }
+    _, height := v.Size()
+    origin := 0
+    if index >= height {
+        origin = index - height + 1
+    }
+    if err := v.SetOrigin(0, origin); err != nil {
+        return err
+    }
+    return v.SetCursor(0, index-origin)
+}
+
 func (crontabPanel *CrontabListPanel) DrawText(g *gocui.Gui, message string) error {
     v, err := g.View(crontabPanel.ViewName)
     if err != nil {
@@ -53,3 +124,11 @@ func (crontabPanel *CrontabListPanel) DrawText(g *gocui.Gui, message string) err
     fmt.Fprintf(v, message)
     return nil
 }
+
+// now is the current time in loc, or in the system's zone for nil.
+func now(loc *time.Location) time.Time {
+    if loc == nil {
+        return time.Now()
+    }
+    return time.Now().In(loc)
+}
@@ -3,12 +3,20 @@ package ui
 import (
     "github.com/jroimartin/gocui"
     "fmt"
+    "sort"
+    "strings"
+    "time"
     "crontab-tui/parser"
 )
 
+const NextRunCount = 5
+
 type DescriptionPanel struct {
     ViewName        string
     viewPosition    ViewPosition
+    // Location is the zone runs are also shown in when the job's schedule
+    // is evaluated in another one, nil for the system's.
+    Location        *time.Location
 }
 
 func NewDescriptionPanel() (*DescriptionPanel, error) {
@@ -16,9 +24,9 @@ func NewDescriptionPanel() (*DescriptionPanel, error) {
         ViewName: "description",
         viewPosition: ViewPosition{
             x0: Position{0.0, 0},
-            y0: Position{0.8, 0},
+            y0: Position{0.55, 0},
             x1: Position{1.0, 1},
-            y1: Position{0.9, 0},
+            y1: Position{0.95, 1},
         },
     }
     return &descriptionPanel, nil
@@ -41,6 +49,112 @@ func (descriptionPanel *DescriptionPanel) DrawText(g *gocui.Gui, item *parser.Cr
         return err
     }
     v.Clear()
-    fmt.Fprintf(v, item.Description)
+    if item.Err != nil {
+        fmt.Fprintf(v, "\033[31mInvalid line, cron ignores it: %v\033[0m\n", item.Err)
+        if item.Source != "" {
+            fmt.Fprintf(v, "Defined in: %s:%d\n", item.Source, item.LineNumber)
+        }
+        fmt.Fprintln(v, "")
+        fmt.Fprintln(v, item.Raw)
+        fmt.Fprintln(v, "")
+        fmt.Fprintln(v, "e to fix it, d to delete it, n for the next invalid line.")
+        return nil
+    }
+    fmt.Fprintln(v, item.Description)
+    if item.User != "" {
+        fmt.Fprintf(v, "Runs as: %s\n", item.User)
+    }
+    if item.Source != "" {
+        fmt.Fprintf(v, "Defined in: %s:%d\n", item.Source, item.LineNumber)
+    }
+    if item.Via != "" && item.Via != parser.ViaAnacron {
+        fmt.Fprintf(v, "Started by: %s\n", item.Via)
+    }
+    drawCommand(v, item)
+    drawEnvironment(v, item)
+    if zone := item.Zone(); zone != "" {
+        if _, err := parser.LoadZone(zone); err != nil {
+            fmt.Fprintf(v, "\033[33mCRON_TZ %s is unknown, cron uses the system's time zone\033[0m\n", zone)
+        }
+    }
+    if item.Disabled {
+        fmt.Fprintln(v, "")
+        fmt.Fprintln(v, "Disabled: this job is commented out and does not run (t to enable).")
+        return nil
+    }
+
+    runs := item.NextRuns(time.Now().In(item.Location()), NextRunCount)
+    if len(runs) == 0 {
+        return nil
+    }
+    display := descriptionPanel.Location
+    if display == nil {
+        display = time.Local
+    }
+    fmt.Fprintln(v, "")
+    fmt.Fprintf(v, "Next %d runs:\n", len(runs))
+    for _, t := range runs {
+        line := t.Format("Mon 2006-01-02 15:04")
+        // Shown in both zones when the list shows another one than the
+        // job runs in.
+        if t.In(display).Format("15:04 MST") != t.Format("15:04 MST") {
+            line = fmt.Sprintf("%s %-5s  %s", line, t.Format("MST"), t.In(display).Format("Mon 15:04 MST"))
+        }
+        if item.Shifted(t) {
+            line += "  (DST skips the scheduled time, runs after the jump)"
+        }
+        fmt.Fprintf(v, "  %s\n", line)
+    }
     return nil
 }
+
+// drawEnvironment shows the PATH and MAILTO a job runs with, and whatever
+// else the crontab sets above it.
+func drawEnvironment(v *gocui.View, item *parser.CronJob) {
+    path, ok := item.Env["PATH"]
+    if !ok {
+        path = parser.DefaultEnv["PATH"] + " (cron default)"
+    }
+    mailto, ok := item.Env["MAILTO"]
+    switch {
+    case !ok && item.User != "":
+        mailto = item.User + " (cron default)"
+    case !ok:
+        mailto = "the crontab's owner (cron default)"
+    case mailto == "":
+        mailto = "(empty, output is not mailed)"
+    }
+    fmt.Fprintf(v, "PATH: %s\n", path)
+    fmt.Fprintf(v, "MAILTO: %s\n", mailto)
+    names := make([]string, 0, len(item.Env))
+    for name := range item.Env {
+        if name != "PATH" && name != "MAILTO" {
+            names = append(names, name)
+        }
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        fmt.Fprintf(v, "%s: %s\n", name, envValue(item.Env[name]))
+    }
+}
+
+// drawCommand splits a command with an unescaped % into what cron hands to
+// the shell and what it writes to the command's standard input.
+func drawCommand(v *gocui.View, item *parser.CronJob) {
+    run, input, hasInput := parser.SplitCommand(item.Command)
+    if !hasInput {
+        return
+    }
+    fmt.Fprintf(v, "Command: %s\n", run)
+    if input == "" {
+        fmt.Fprintln(v, "Standard input: (empty)")
+    } else {
+        fmt.Fprintln(v, "Standard input:")
+        for _, line := range strings.Split(strings.TrimSuffix(input, "\n"), "\n") {
+            fmt.Fprintf(v, "  \033[36m%s\033[0m\n", line)
+        }
+    }
+    if parser.UnintendedPercent(item.Command) >= 0 {
+        fmt.Fprintln(v, "\033[33mThe % looks meant for the command: e, then Tab, escapes it as \\%\033[0m")
+    }
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+    "github.com/jroimartin/gocui"
+    "fmt"
+    "crontab-tui/lint"
+)
+
+// DiagnosticsPanel lists what the linter found, one finding per row.
+type DiagnosticsPanel struct {
+    ViewName     string
+    viewPosition ViewPosition
+    Diagnostics  []lint.Diagnostic
+}
+
+func NewDiagnosticsPanel() (*DiagnosticsPanel, error) {
+    diagnosticsPanel := DiagnosticsPanel{
+        ViewName: "diagnostics",
+        viewPosition: ViewPosition {
+            x0: Position{0.05, 0},
+            y0: Position{0.15, 0},
+            x1: Position{0.95, 2},
+            y1: Position{0.75, 2},
+        },
+    }
+    return &diagnosticsPanel, nil
+}
+
+var severityColors = map[lint.Severity]string{
+    lint.Error:   "\033[31m",
+    lint.Warning: "\033[33m",
+    lint.Info:    "\033[36m",
+}
+
+// DrawView shows the diagnostics, prefixed with their source when they
+// come from several crontabs.
+func (diagnosticsPanel *DiagnosticsPanel) DrawView(g *gocui.Gui, diagnostics []lint.Diagnostic, withSource bool) error {
+    diagnosticsPanel.Diagnostics = diagnostics
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := diagnosticsPanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(diagnosticsPanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    v.Title = fmt.Sprintf(" %d error(s), %d warning(s) ── Enter: Go to line  f: Apply fix  Esc: Close ",
+        lint.Count(diagnostics, lint.Error), lint.Count(diagnostics, lint.Warning)-lint.Count(diagnostics, lint.Error))
+    v.SelFgColor = gocui.ColorBlue
+    v.SelBgColor = gocui.ColorGreen
+    v.Highlight = true
+    v.Clear()
+    v.SetCursor(0, 0)
+    v.SetOrigin(0, 0)
+    for _, d := range diagnostics {
+        position := fmt.Sprintf("%d:%d", d.Line, d.Column)
+        if withSource {
+            position = d.Source + ":" + position
+        }
+        fmt.Fprintf(v, "%-8s %s%-7s\033[0m %s [%s]\n", position, severityColors[d.Severity], d.Severity, d.Message, d.Rule)
+    }
+    if len(diagnostics) == 0 {
+        fmt.Fprintln(v, "No problems found.")
+    }
+    if _, err := g.SetCurrentView(diagnosticsPanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
+
+// Selected returns the diagnostic under the cursor.
+func (diagnosticsPanel *DiagnosticsPanel) Selected(g *gocui.Gui) (lint.Diagnostic, bool) {
+    v, err := g.View(diagnosticsPanel.ViewName)
+    if err != nil {
+        return lint.Diagnostic{}, false
+    }
+    _, oy := v.Origin()
+    _, cy := v.Cursor()
+    idx := oy + cy
+    if idx < 0 || idx >= len(diagnosticsPanel.Diagnostics) {
+        return lint.Diagnostic{}, false
+    }
+    return diagnosticsPanel.Diagnostics[idx], true
+}
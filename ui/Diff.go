@@ -0,0 +1,73 @@
+package ui
+
+import (
+    "github.com/jroimartin/gocui"
+    "fmt"
+    "strings"
+)
+
+// DiffPanel shows a unified diff, e.g. of the staged changes.
+type DiffPanel struct {
+    ViewName     string
+    viewPosition ViewPosition
+}
+
+func NewDiffPanel() (*DiffPanel, error) {
+    diffPanel := DiffPanel{
+        ViewName: "diff",
+        viewPosition: ViewPosition {
+            x0: Position{0.05, 0},
+            y0: Position{0.05, 0},
+            x1: Position{0.95, 2},
+            y1: Position{0.9, 2},
+        },
+    }
+    return &diffPanel, nil
+}
+
+func (diffPanel *DiffPanel) DrawView(g *gocui.Gui, title string, text string) error {
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := diffPanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(diffPanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    v.Title = " " + title + " ── j/k: Scroll  w: Save  x: Discard  D/q: Close "
+    v.Clear()
+    v.SetOrigin(0, 0)
+    if text == "" {
+        fmt.Fprintln(v, "No differences.")
+    }
+    for _, line := range strings.SplitAfter(text, "\n") {
+        switch {
+        case line == "":
+        case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+            fmt.Fprintf(v, "\033[1m%s\033[0m\n", strings.TrimSuffix(line, "\n"))
+        case strings.HasPrefix(line, "@@"):
+            fmt.Fprintf(v, "\033[36m%s\033[0m\n", strings.TrimSuffix(line, "\n"))
+        case strings.HasPrefix(line, "-"):
+            fmt.Fprintf(v, "\033[31m%s\033[0m\n", strings.TrimSuffix(line, "\n"))
+        case strings.HasPrefix(line, "+"):
+            fmt.Fprintf(v, "\033[32m%s\033[0m\n", strings.TrimSuffix(line, "\n"))
+        default:
+            fmt.Fprint(v, line)
+        }
+    }
+    if _, err := g.SetCurrentView(diffPanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
+
+// Scroll moves the diff by d lines.
+func (diffPanel *DiffPanel) Scroll(g *gocui.Gui, d int) error {
+    v, err := g.View(diffPanel.ViewName)
+    if err != nil {
+        return err
+    }
+    _, oy := v.Origin()
+    _, height := v.Size()
+    lines := len(v.BufferLines())
+    oy = max(min(oy+d, lines-height), 0)
+    return v.SetOrigin(0, oy)
+}
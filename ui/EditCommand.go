@@ -0,0 +1,65 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+    "github.com/jroimartin/gocui"
+    "crontab-tui/parser"
+)
+
+type EditCommandPanel struct {
+    ViewName    string
+    viewPosition ViewPosition
+    HasError    bool
+    Job         *parser.CronJob
+}
+
+func NewEditCommandPanel() (*EditCommandPanel, error) {
+    editCommandPanel := EditCommandPanel{
+        ViewName: "Edit Command",
+        viewPosition: ViewPosition {
+            x0: Position{0.1, 0},
+            y0: Position{0.35, 0},
+            x1: Position{0.9, 2},
+            y1: Position{0.5, 2},
+        },
+        HasError: false,
+    }
+    return &editCommandPanel, nil
+}
+
+// DrawView opens the editor pre-filled with the job's schedule and command.
+func (editCommandPanel *EditCommandPanel) DrawView(g *gocui.Gui, job *parser.CronJob) error {
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := editCommandPanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(editCommandPanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    editCommandPanel.Job = job
+    editCommandPanel.HasError = false
+    v.SelFgColor = gocui.ColorBlack
+    v.Editable = true
+    v.Title = fmt.Sprintf(" Edit crontab job (line %d) ", job.LineNumber)
+    v.Clear()
+
+    text := parser.FormatJobLine(job.Schedule, job.User, job.Command)
+    if job.Err != nil {
+        v.Title = fmt.Sprintf(" Fix line %d: %v ", job.LineNumber, job.Err)
+        text = strings.TrimSpace(job.Raw)
+    }
+    fmt.Fprint(v, text)
+    width, _ := v.Size()
+    if len(text) < width {
+        v.SetOrigin(0, 0)
+        v.SetCursor(len(text), 0)
+    } else {
+        v.SetOrigin(len(text)-width+1, 0)
+        v.SetCursor(width-1, 0)
+    }
+
+    if _, err := g.SetCurrentView(editCommandPanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+    "github.com/jroimartin/gocui"
+    "fmt"
+    "strconv"
+    "strings"
+    "crontab-tui/parser"
+)
+
+// EnvPanel lists the variable assignments of a crontab.
+type EnvPanel struct {
+    ViewName     string
+    viewPosition ViewPosition
+    Lines        []*parser.Line
+}
+
+func NewEnvPanel() (*EnvPanel, error) {
+    envPanel := EnvPanel{
+        ViewName: "env",
+        viewPosition: ViewPosition {
+            x0: Position{0.1, 0},
+            y0: Position{0.15, 0},
+            x1: Position{0.9, 2},
+            y1: Position{0.6, 2},
+        },
+    }
+    return &envPanel, nil
+}
+
+// DrawView shows the assignments, keeping the cursor where it was when the
+// panel is already open.
+func (envPanel *EnvPanel) DrawView(g *gocui.Gui, name string, lines []*parser.Line) error {
+    envPanel.Lines = lines
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := envPanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(envPanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    if err == gocui.ErrUnknownView {
+        v.SelFgColor = gocui.ColorBlue
+        v.SelBgColor = gocui.ColorGreen
+        v.Highlight = true
+    }
+    v.Title = fmt.Sprintf(" Environment of %s ── a: Add  e: Edit  d: Delete  Esc: Close ", name)
+    v.Clear()
+    for _, line := range lines {
+        fmt.Fprintf(v, "%4d  %-12s %s\n", line.Number, line.Env.Name, envValue(line.Env.Value))
+    }
+    if len(lines) == 0 {
+        fmt.Fprintln(v, "No variables set, jobs run with cron's defaults. a adds one.")
+    }
+    _, oy := v.Origin()
+    _, cy := v.Cursor()
+    if oy+cy >= len(lines) {
+        v.SetOrigin(0, 0)
+        v.SetCursor(0, 0)
+    }
+    if _, err := g.SetCurrentView(envPanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
+
+// Selected returns the assignment under the cursor.
+func (envPanel *EnvPanel) Selected(g *gocui.Gui) (*parser.Line, bool) {
+    v, err := g.View(envPanel.ViewName)
+    if err != nil {
+        return nil, false
+    }
+    _, oy := v.Origin()
+    _, cy := v.Cursor()
+    idx := oy + cy
+    if idx < 0 || idx >= len(envPanel.Lines) {
+        return nil, false
+    }
+    return envPanel.Lines[idx], true
+}
+
+// envValue quotes values whose blanks would otherwise not show.
+func envValue(value string) string {
+    if value == "" || value != strings.TrimSpace(value) {
+        return strconv.Quote(value)
+    }
+    return value
+}
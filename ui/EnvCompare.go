@@ -0,0 +1,66 @@
+package ui
+
+import (
+    "github.com/jroimartin/gocui"
+    "fmt"
+)
+
+// EnvComparePanel shows the environment cron gives a job next to the one
+// crontab-tui runs in.
+type EnvComparePanel struct {
+    ViewName     string
+    viewPosition ViewPosition
+}
+
+func NewEnvComparePanel() (*EnvComparePanel, error) {
+    envComparePanel := EnvComparePanel{
+        ViewName: "envcompare",
+        viewPosition: ViewPosition {
+            x0: Position{0.05, 0},
+            y0: Position{0.05, 0},
+            x1: Position{0.95, 2},
+            y1: Position{0.9, 2},
+        },
+    }
+    return &envComparePanel, nil
+}
+
+// DrawView opens the panel with the comparison written by
+// runner.WriteComparison.
+func (envComparePanel *EnvComparePanel) DrawView(g *gocui.Gui, title string, text string) error {
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := envComparePanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(envComparePanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    v.Title = " " + title + " ── j/k: Scroll  C/q: Close "
+    v.Clear()
+    v.SetOrigin(0, 0)
+    fmt.Fprint(v, text)
+    if _, err := g.SetCurrentView(envComparePanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
+
+// ValueWidth is how wide each value column can be.
+func (envComparePanel *EnvComparePanel) ValueWidth(g *gocui.Gui) int {
+    maxX, maxY := g.Size()
+    x0, _, x1, _ := envComparePanel.viewPosition.GetCoordinates(maxX, maxY)
+    // Leave room for the mark, a name and the separators.
+    return max((x1-x0-32)/2, 10)
+}
+
+// Scroll moves the comparison by d lines.
+func (envComparePanel *EnvComparePanel) Scroll(g *gocui.Gui, d int) error {
+    v, err := g.View(envComparePanel.ViewName)
+    if err != nil {
+        return err
+    }
+    _, oy := v.Origin()
+    _, height := v.Size()
+    lines := len(v.BufferLines())
+    oy = max(min(oy+d, lines-height), 0)
+    return v.SetOrigin(0, oy)
+}
@@ -0,0 +1,153 @@
+package ui
+
+import (
+    "github.com/jroimartin/gocui"
+    "bytes"
+    "fmt"
+    "sync"
+)
+
+// OutputLines is how many lines of output the panel keeps, older ones are
+// dropped so that a chatty job cannot fill the memory.
+const OutputLines = 5000
+
+// OutputPanel shows what a running job writes. Append may be called from
+// any goroutine, Flush moves the written data to the view in the main
+// loop.
+type OutputPanel struct {
+    ViewName     string
+    viewPosition ViewPosition
+    title        string
+    header       string
+    mu           sync.Mutex
+    pending      bytes.Buffer
+    // kept is the output in the view, dropped the number of lines cut
+    // from its start.
+    kept         []byte
+    dropped      int
+}
+
+func NewOutputPanel() (*OutputPanel, error) {
+    outputPanel := OutputPanel{
+        ViewName: "output",
+        viewPosition: ViewPosition {
+            x0: Position{0.05, 0},
+            y0: Position{0.05, 0},
+            x1: Position{0.95, 2},
+            y1: Position{0.9, 2},
+        },
+    }
+    return &outputPanel, nil
+}
+
+// DrawView opens the panel with header lines above the output.
+func (outputPanel *OutputPanel) DrawView(g *gocui.Gui, title string, header string) error {
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := outputPanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(outputPanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    outputPanel.mu.Lock()
+    outputPanel.pending.Reset()
+    outputPanel.mu.Unlock()
+    outputPanel.title, outputPanel.header = title, header
+    outputPanel.kept, outputPanel.dropped = nil, 0
+    v.Title = " " + title + " ── j/k: Scroll  c: Cancel  q: Close "
+    v.Wrap = true
+    v.Autoscroll = true
+    v.Clear()
+    v.SetOrigin(0, 0)
+    fmt.Fprintf(v, "\033[1m%s\033[0m\n", header)
+    if _, err := g.SetCurrentView(outputPanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
+
+// Append adds output for the next Flush and reports whether nothing was
+// pending before, that is whether a Flush has to be queued for p. Until
+// that Flush runs, further output joins it.
+func (outputPanel *OutputPanel) Append(p []byte) bool {
+    outputPanel.mu.Lock()
+    defer outputPanel.mu.Unlock()
+    first := outputPanel.pending.Len() == 0
+    outputPanel.pending.Write(p)
+    return first && len(p) > 0
+}
+
+// Flush shows what was written since the last call. It is a no-op once
+// the panel is closed.
+func (outputPanel *OutputPanel) Flush(g *gocui.Gui) error {
+    outputPanel.mu.Lock()
+    data := bytes.ReplaceAll(outputPanel.pending.Bytes(), []byte("\r"), nil)
+    outputPanel.pending.Reset()
+    outputPanel.mu.Unlock()
+    v, err := g.View(outputPanel.ViewName)
+    if err != nil {
+        return nil
+    }
+    outputPanel.kept = append(outputPanel.kept, data...)
+    lines := bytes.Count(outputPanel.kept, []byte("\n"))
+    if lines <= OutputLines {
+        v.Write(data)
+        return nil
+    }
+    // Cut down to three quarters, not to the limit, so that the view is
+    // not rebuilt on every flush from then on.
+    cut := lines - OutputLines*3/4
+    at := 0
+    for i := 0; i < cut; i++ {
+        at += bytes.IndexByte(outputPanel.kept[at:], '\n') + 1
+    }
+    outputPanel.kept = append([]byte(nil), outputPanel.kept[at:]...)
+    outputPanel.dropped += cut
+    v.Clear()
+    fmt.Fprintf(v, "\033[1m%s\033[0m\n", outputPanel.header)
+    fmt.Fprintf(v, "\033[2m── %d earlier lines dropped\033[0m\n", outputPanel.dropped)
+    v.Write(outputPanel.kept)
+    if !v.Autoscroll {
+        _, oy := v.Origin()
+        v.SetOrigin(0, max(oy-cut, 0))
+    }
+    return nil
+}
+
+// Finish shows the remaining output and how the run ended.
+func (outputPanel *OutputPanel) Finish(g *gocui.Gui, status string, ok bool) error {
+    outputPanel.Flush(g)
+    v, err := g.View(outputPanel.ViewName)
+    if err != nil {
+        return nil
+    }
+    color := 31
+    if ok {
+        color = 32
+    }
+    // Output without a final newline still gets the status on a line of
+    // its own.
+    if lines := v.BufferLines(); len(lines) > 0 && lines[len(lines)-1] != "" {
+        fmt.Fprintln(v, "")
+    }
+    fmt.Fprintf(v, "\033[%dm── %s\033[0m\n", color, status)
+    v.Title = " " + outputPanel.title + " ── j/k: Scroll  q: Close "
+    return nil
+}
+
+// Scroll moves the output by d lines. Scrolling up stops following new
+// output, scrolling back to the end resumes it.
+func (outputPanel *OutputPanel) Scroll(g *gocui.Gui, d int) error {
+    v, err := g.View(outputPanel.ViewName)
+    if err != nil {
+        return err
+    }
+    _, oy := v.Origin()
+    _, height := v.Size()
+    lines := len(v.BufferLines())
+    if v.Autoscroll {
+        oy = max(lines-height, 0)
+    }
+    oy = max(min(oy+d, lines-height), 0)
+    v.Autoscroll = oy >= lines-height
+    return v.SetOrigin(0, oy)
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+    "github.com/jroimartin/gocui"
+    "fmt"
+)
+
+// PathPanel asks for a single line of text, e.g. a file name to export
+// jobs to or a variable assignment.
+type PathPanel struct {
+    ViewName     string
+    viewPosition ViewPosition
+    title        string
+    HasError     bool
+}
+
+func NewPathPanel(viewName string, title string) (*PathPanel, error) {
+    pathPanel := PathPanel{
+        ViewName: viewName,
+        viewPosition: ViewPosition {
+            x0: Position{0.1, 0},
+            y0: Position{0.35, 0},
+            x1: Position{0.9, 2},
+            y1: Position{0.5, 2},
+        },
+        title: title,
+    }
+    return &pathPanel, nil
+}
+
+// DrawView opens the popup pre-filled with path.
+func (pathPanel *PathPanel) DrawView(g *gocui.Gui, path string) error {
+    maxX, maxY := g.Size()
+    x0, y0, x1, y1 := pathPanel.viewPosition.GetCoordinates(maxX, maxY)
+    v, err := g.SetView(pathPanel.ViewName, x0, y0, x1, y1)
+    if err != nil && err != gocui.ErrUnknownView {
+        return err
+    }
+    pathPanel.HasError = false
+    v.SelFgColor = gocui.ColorBlack
+    v.Editable = true
+    v.Title = " " + pathPanel.title + " "
+    v.Clear()
+    fmt.Fprint(v, path)
+    v.SetOrigin(0, 0)
+    v.SetCursor(len(path), 0)
+    if _, err := g.SetCurrentView(pathPanel.ViewName); err != nil {
+        return err
+    }
+    return nil
+}
+
+// SetTitle changes the title for the next DrawView.
+func (pathPanel *PathPanel) SetTitle(title string) {
+    pathPanel.title = title
+}
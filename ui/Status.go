@@ -5,9 +5,13 @@ import (
     "fmt"
 )
 
+const statusHints = "j: Down\tk: Up\te: Edit\td: Delete\tt: Enable/Disable\tu: Undo\tb: Backups\tS: Staging\tE: Export\tI: Import\tL: Lint\tv: Variables\tz: Time zone\tr: Run\tC: Cron env\tCtrl+F: Add\tTab: Source\tq: Quit"
+
 type StatusPanel struct {
     ViewName string
     viewPosition ViewPosition
+    message  string
+    mode     string
 }
 
 func NewStatusPanel() (*StatusPanel, error) {
@@ -33,7 +37,49 @@ func (statusPanel *StatusPanel) DrawView(g *gocui.Gui) error {
         v.SelFgColor = gocui.ColorBlue
         v.SelBgColor = gocui.ColorGreen
         v.Frame = false
-        fmt.Fprintln(v, "j: Down\tk: Up")
+        statusPanel.draw(v)
+    }
+    return nil
+}
+
+// SetMessage shows a short message before the key hints, an empty
+// message clears it.
+func (statusPanel *StatusPanel) SetMessage(g *gocui.Gui, message string) error {
+    statusPanel.message = message
+    v, err := g.View(statusPanel.ViewName)
+    if err != nil {
+        return err
     }
+    statusPanel.draw(v)
     return nil
 }
+
+// SetMode shows a lasting indicator such as the number of staged changes,
+// an empty mode removes it.
+func (statusPanel *StatusPanel) SetMode(g *gocui.Gui, mode string) error {
+    statusPanel.mode = mode
+    v, err := g.View(statusPanel.ViewName)
+    if err != nil {
+        return err
+    }
+    statusPanel.draw(v)
+    return nil
+}
+
+// SetError is SetMessage in red.
+func (statusPanel *StatusPanel) SetError(g *gocui.Gui, err error) error {
+    return statusPanel.SetMessage(g, fmt.Sprintf("\033[31m%v\033[0m", err))
+}
+
+func (statusPanel *StatusPanel) draw(v *gocui.View) {
+    v.Clear()
+    line := statusHints
+    // The message goes first, the hints are cut off on narrow terminals.
+    if statusPanel.message != "" {
+        line = fmt.Sprintf("%s │ %s", statusPanel.message, line)
+    }
+    if statusPanel.mode != "" {
+        line = fmt.Sprintf("\033[7m %s \033[0m %s", statusPanel.mode, line)
+    }
+    fmt.Fprintln(v, line)
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "os"
+    "os/user"
+    "strconv"
+    "strings"
+)
+
+// PasswdFile is the passwd database used for user lookups.
+var PasswdFile = "/etc/passwd"
+
+type PasswdEntry struct {
+    Name  string
+    UID   int
+    GID   int
+    Gecos string
+    Home  string
+    Shell string
+}
+
+// lookupNSS asks the name service switch, set by tests.
+var lookupNSS = user.Lookup
+
+// LookupPasswd finds name the way cron does, through NSS: the local passwd
+// file first, then the other databases such as LDAP or SSSD. Those only
+// give the name, ids, GECOS and home directory, Shell stays empty.
+func LookupPasswd(name string) (*PasswdEntry, error) {
+    entry, err := lookupPasswdFile(name)
+    if entry != nil || (err != nil && err != errNotInFile) {
+        return entry, err
+    }
+    u, err := lookupNSS(name)
+    if err != nil {
+        return nil, fmt.Errorf("unknown user: %s", name)
+    }
+    uid, err1 := strconv.Atoi(u.Uid)
+    gid, err2 := strconv.Atoi(u.Gid)
+    if err1 != nil || err2 != nil {
+        return nil, fmt.Errorf("malformed passwd entry for %s", name)
+    }
+    return &PasswdEntry{Name: u.Username, UID: uid, GID: gid, Gecos: u.Name, Home: u.HomeDir}, nil
+}
+
+var errNotInFile = errors.New("not in the passwd file")
+
+// lookupPasswdFile reads PasswdFile, a missing file is treated as one
+// without the user.
+func lookupPasswdFile(name string) (*PasswdEntry, error) {
+    f, err := os.Open(PasswdFile)
+    if os.IsNotExist(err) {
+        return nil, errNotInFile
+    }
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        line := scanner.Text()
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        parts := strings.Split(line, ":")
+        if len(parts) < 7 || parts[0] != name {
+            continue
+        }
+        uid, err1 := strconv.Atoi(parts[2])
+        gid, err2 := strconv.Atoi(parts[3])
+        if err1 != nil || err2 != nil {
+            return nil, fmt.Errorf("malformed passwd entry for %s", name)
+        }
+        return &PasswdEntry{
+            Name:  parts[0],
+            UID:   uid,
+            GID:   gid,
+            Gecos: parts[4],
+            Home:  parts[5],
+            Shell: parts[6],
+        }, nil
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+    return nil, errNotInFile
+}
+
+func ValidateUser(name string) error {
+    if name == "" {
+        return fmt.Errorf("missing user")
+    }
+    _, err := LookupPasswd(name)
+    return err
+}
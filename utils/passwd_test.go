@@ -0,0 +1,85 @@
+package utils
+
+import (
+    "errors"
+    "os"
+    "os/user"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestLookupPasswd(t *testing.T) {
+    dir := t.TempDir()
+    passwd := filepath.Join(dir, "passwd")
+    content := "# local accounts\nroot:x:0:0:root:/root:/bin/bash\n\nalice:x:1000:1000:Alice:/home/alice:/bin/zsh\nbroken:x:uid:100::/:/bin/sh\nshort:x:1\n"
+    if err := os.WriteFile(passwd, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+    defer func(file string, nss func(string) (*user.User, error)) {
+        PasswdFile, lookupNSS = file, nss
+    }(PasswdFile, lookupNSS)
+    lookupNSS = func(name string) (*user.User, error) {
+        if name == "carol" {
+            return &user.User{Uid: "5000", Gid: "100", Username: "carol", Name: "Carol", HomeDir: "/net/home/carol"}, nil
+        }
+        return nil, user.UnknownUserError(name)
+    }
+
+    tests := []struct {
+        file    string
+        name    string
+        want    *PasswdEntry
+        wantErr string
+    }{
+        {passwd, "alice", &PasswdEntry{"alice", 1000, 1000, "Alice", "/home/alice", "/bin/zsh"}, ""},
+        {passwd, "root", &PasswdEntry{"root", 0, 0, "root", "/root", "/bin/bash"}, ""},
+        {passwd, "carol", &PasswdEntry{"carol", 5000, 100, "Carol", "/net/home/carol", ""}, ""},
+        {passwd, "broken", nil, "malformed passwd entry for broken"},
+        {passwd, "short", nil, "unknown user: short"},
+        {passwd, "nobody-here", nil, "unknown user: nobody-here"},
+        {filepath.Join(dir, "missing"), "carol", &PasswdEntry{"carol", 5000, 100, "Carol", "/net/home/carol", ""}, ""},
+        {filepath.Join(dir, "missing"), "alice", nil, "unknown user: alice"},
+    }
+
+    for _, tt := range tests {
+        PasswdFile = tt.file
+        got, err := LookupPasswd(tt.name)
+        if tt.wantErr != "" {
+            if err == nil || err.Error() != tt.wantErr {
+                t.Errorf("LookupPasswd(%q) error %v, want %q", tt.name, err, tt.wantErr)
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("LookupPasswd(%q): %v", tt.name, err)
+            continue
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("LookupPasswd(%q) = %+v, want %+v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestValidateUser(t *testing.T) {
+    defer func(file string, nss func(string) (*user.User, error)) {
+        PasswdFile, lookupNSS = file, nss
+    }(PasswdFile, lookupNSS)
+    PasswdFile = filepath.Join(t.TempDir(), "missing")
+    lookupNSS = func(name string) (*user.User, error) {
+        if name == "ldapuser" {
+            return &user.User{Uid: "7000", Gid: "7000", Username: name}, nil
+        }
+        return nil, errors.New("no such user")
+    }
+
+    if err := ValidateUser(""); err == nil {
+        t.Error("empty user accepted")
+    }
+    if err := ValidateUser("ldapuser"); err != nil {
+        t.Errorf("user from NSS rejected: %v", err)
+    }
+    if err := ValidateUser("ghost"); err == nil {
+        t.Error("unknown user accepted")
+    }
+}